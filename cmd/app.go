@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/CameronXie/go-opa-reviewer/internal/app"
+	"github.com/CameronXie/go-opa-reviewer/internal/audit"
 	"github.com/CameronXie/go-opa-reviewer/internal/prhandler"
 	"github.com/CameronXie/go-opa-reviewer/internal/review"
 	"github.com/CameronXie/go-opa-reviewer/internal/version"
@@ -17,6 +18,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/awslabs/aws-lambda-go-api-proxy/httpadapter"
+	"github.com/open-policy-agent/opa/bundle"
 	"github.com/palantir/go-githubapp/githubapp"
 	"github.com/rs/zerolog"
 )
@@ -28,23 +30,100 @@ const (
 	reviewerPoolSize = 100
 	secretIdEnv      = "GITHUB_APP_SECRET_ID"
 	policyQueryEnv   = "GITHUB_APP_POLICY_QUERY"
+	policyRoutesEnv  = "GITHUB_APP_POLICY_ROUTES"
+	changesetEnv     = "GITHUB_APP_CHANGESET_QUERY"
+	pullRequestEnv   = "GITHUB_APP_PULL_REQUEST_QUERY"
+	envelopeEnv      = "GITHUB_APP_POLICY_ENVELOPE"
+	minimizeEnv      = "GITHUB_APP_MINIMIZE_OUTDATED_COMMENTS"
 	filePatterns     = "GITHUB_APP_FILE_PATTERNS"
+	modeEnv          = "GITHUB_APP_MODE"
+	auditMode        = "audit"
+	reviewBranchMode = "review-branches"
+	serverMode       = "server"
+	defaultRoute     = "default"
 	logLevel         = zerolog.DebugLevel
 )
 
+// components are the dependencies shared by the webhook handler, the audit and the scheduled branch reviews.
+type components struct {
+	appConfig     *githubapp.Config
+	clientCreator githubapp.ClientCreator
+	policyBundle  *bundle.Bundle
+	routes        []review.Route
+	reviewSvc     review.Service
+}
+
 func main() {
 	logger := zerolog.New(os.Stdout).With().Timestamp().Logger()
 	zerolog.DefaultContextLogger = &logger
 
-	awsConfig, awsConfigErr := config.LoadDefaultConfig(context.TODO())
-	checkError(awsConfigErr)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case auditCommand:
+			checkError(runAuditCommand(context.Background(), os.Args[2:]))
+			return
+		case serveCommand:
+			checkError(runServeCommand(context.Background(), os.Args[2:]))
+			return
+		case reviewBranchesCommand:
+			checkError(runReviewBranchesCommand(context.Background(), os.Args[2:]))
+			return
+		}
+	}
 
-	appConfig, configErr := app.GetAppConfigFromSecret(
-		context.Background(),
-		secretsmanager.NewFromConfig(awsConfig),
-		os.Getenv(secretIdEnv),
-	)
-	checkError(configErr)
+	if os.Getenv(modeEnv) == serverMode {
+		checkError(runServeCommand(context.Background(), nil))
+		return
+	}
+
+	c, setupErr := setup(context.Background(), bundlePath)
+	checkError(setupErr)
+
+	if os.Getenv(modeEnv) == auditMode {
+		auditor, auditorErr := audit.New(c.clientCreator, review.Matcher(c.routes), c.reviewSvc)
+		checkError(auditorErr)
+
+		lambda.Start(scheduledAuditHandler(auditor))
+		return
+	}
+
+	if os.Getenv(modeEnv) == reviewBranchMode {
+		lambda.Start(scheduledReviewBranchesHandler(newBranchReviewer(c)))
+		return
+	}
+
+	webhookHandler, handlerErr := newWebhookHandler(context.Background(), c)
+	checkError(handlerErr)
+
+	http.Handle(githubapp.DefaultWebhookRoute, webhookHandler)
+	lambda.Start(httpadapter.NewALB(http.DefaultServeMux).ProxyWithContext)
+}
+
+// newWebhookHandler dispatches the webhook deliveries to the pull request handler.
+func newWebhookHandler(ctx context.Context, c *components) (http.Handler, error) {
+	handlerOpts, handlerOptsErr := getHandlerOptions(ctx, c.policyBundle)
+	if handlerOptsErr != nil {
+		return nil, handlerOptsErr
+	}
+
+	return githubapp.NewDefaultEventDispatcher(
+		*c.appConfig,
+		prhandler.New(
+			c.clientCreator,
+			review.Matcher(c.routes),
+			c.reviewSvc,
+			handlerOpts...,
+		),
+	), nil
+}
+
+// setup loads the GitHub App config from the secret env, or from the app envs when it is not set, and the policy bundle
+// from the given path, and builds the GitHub client creator and the review service.
+func setup(ctx context.Context, path string) (*components, error) {
+	appConfig, configErr := getAppConfig(ctx)
+	if configErr != nil {
+		return nil, configErr
+	}
 
 	githubClientCreator, clientErr := githubapp.NewDefaultCachingClientCreator(
 		*appConfig,
@@ -54,25 +133,124 @@ func main() {
 			githubapp.ClientLogging(logLevel),
 		),
 	)
-	checkError(clientErr)
+	if clientErr != nil {
+		return nil, clientErr
+	}
 
-	fileReviewer, reviewerErr := reviewer.NewReviewerWithBundle(
-		context.Background(),
-		os.Getenv(policyQueryEnv),
-		bundlePath,
-	)
-	checkError(reviewerErr)
+	policyBundle, bundleErr := reviewer.LoadBundle(path)
+	if bundleErr != nil {
+		return nil, bundleErr
+	}
 
-	reviewSvc, svcErr := review.New(fileReviewer, readerPoolSize, reviewerPoolSize)
-	checkError(svcErr)
+	routes, routesErr := getRoutes(ctx, policyBundle)
+	if routesErr != nil {
+		return nil, routesErr
+	}
 
-	webhookHandler := githubapp.NewDefaultEventDispatcher(
-		*appConfig,
-		prhandler.New(githubClientCreator, app.GetPatternsFromCSV(os.Getenv(filePatterns)), reviewSvc),
-	)
+	svcOpts, svcOptsErr := getServiceOptions(ctx, policyBundle)
+	if svcOptsErr != nil {
+		return nil, svcOptsErr
+	}
 
-	http.Handle(githubapp.DefaultWebhookRoute, webhookHandler)
-	lambda.Start(httpadapter.NewALB(http.DefaultServeMux).ProxyWithContext)
+	reviewSvc, svcErr := review.New(routes, readerPoolSize, reviewerPoolSize, svcOpts...)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+
+	return &components{
+		appConfig:     appConfig,
+		clientCreator: githubClientCreator,
+		policyBundle:  policyBundle,
+		routes:        routes,
+		reviewSvc:     reviewSvc,
+	}, nil
+}
+
+// getAppConfig retrieves the GitHub App config from the secret env when it is set, e.g. on Lambda, or from the app
+// envs otherwise, e.g. when served from a container.
+func getAppConfig(ctx context.Context) (*githubapp.Config, error) {
+	secretID := os.Getenv(secretIdEnv)
+	if secretID == "" {
+		return app.GetAppConfigFromEnv(os.Getenv)
+	}
+
+	awsConfig, awsConfigErr := config.LoadDefaultConfig(ctx)
+	if awsConfigErr != nil {
+		return nil, awsConfigErr
+	}
+
+	return app.GetAppConfigFromSecret(ctx, secretsmanager.NewFromConfig(awsConfig), secretID)
+}
+
+// getRoutes builds the review routes from the routes env, or a single default route from the policy query, file
+// patterns and envelope envs when the routes env is not set.
+func getRoutes(ctx context.Context, policyBundle *bundle.Bundle) ([]review.Route, error) {
+	routeConfigs := []app.RouteConfig{{
+		Name:     defaultRoute,
+		Query:    os.Getenv(policyQueryEnv),
+		Patterns: app.GetPatternsFromCSV(os.Getenv(filePatterns)),
+		Envelope: os.Getenv(envelopeEnv) == "true",
+	}}
+
+	if routesJSON := os.Getenv(policyRoutesEnv); routesJSON != "" {
+		configs, err := app.GetRoutesFromJSON(routesJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		routeConfigs = configs
+	}
+
+	routes := make([]review.Route, 0, len(routeConfigs))
+	for _, cfg := range routeConfigs {
+		fileReviewer, err := reviewer.NewReviewer(ctx, cfg.Query, policyBundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create reviewer for route %s: %w", cfg.Name, err)
+		}
+
+		routes = append(routes, review.Route{
+			Name:     cfg.Name,
+			Patterns: cfg.Patterns,
+			Types:    cfg.Types,
+			Envelope: cfg.Envelope,
+			Reviewer: fileReviewer,
+		})
+	}
+
+	return routes, nil
+}
+
+// getServiceOptions reviews the changed files as a whole with the changeset query env when it is set.
+func getServiceOptions(ctx context.Context, policyBundle *bundle.Bundle) ([]review.ServiceOption, error) {
+	query := os.Getenv(changesetEnv)
+	if query == "" {
+		return nil, nil
+	}
+
+	changesetReviewer, err := reviewer.NewChangesetReviewer(ctx, query, policyBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create changeset reviewer: %w", err)
+	}
+
+	return []review.ServiceOption{review.WithChangesetReviewer(changesetReviewer)}, nil
+}
+
+// getHandlerOptions configures the pull request handler with the minimize env, and reviews the metadata of the pull
+// requests with the pull request query env when it is set.
+func getHandlerOptions(ctx context.Context, policyBundle *bundle.Bundle) ([]prhandler.Option, error) {
+	opts := []prhandler.Option{prhandler.WithMinimizeOutdatedComments(os.Getenv(minimizeEnv) == "true")}
+
+	query := os.Getenv(pullRequestEnv)
+	if query == "" {
+		return opts, nil
+	}
+
+	prReviewer, err := reviewer.NewPullRequestReviewer(ctx, query, policyBundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pull request reviewer: %w", err)
+	}
+
+	return append(opts, prhandler.WithPullRequestReviewer(prReviewer)), nil
 }
 
 func checkError(err error) {
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/audit"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/rs/zerolog"
+)
+
+const auditCommand = "audit"
+
+// auditEvent is the input of the scheduled audit, e.g. the constant input of an EventBridge schedule.
+type auditEvent struct {
+	InstallationID int64  `json:"installation_id"`
+	Format         string `json:"format"`
+}
+
+// runAuditCommand audits the repositories of an installation from the command line, and writes the report to the
+// output file, or to stdout if none is given.
+func runAuditCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet(auditCommand, flag.ContinueOnError)
+	installationID := flags.Int64("installation", 0, "ID of the installation whose repositories are audited")
+	format := flags.String("format", audit.FormatMarkdown, "format of the report, one of json, markdown and csv")
+	output := flags.String("output", "", "file the report is written to, stdout if empty")
+	path := flags.String("bundle", bundlePath, "path of the policy bundle")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return err
+	}
+
+	if *installationID == 0 {
+		return errors.New("installation is required")
+	}
+
+	c, setupErr := setup(ctx, *path)
+	if setupErr != nil {
+		return setupErr
+	}
+
+	auditor, auditorErr := audit.New(c.clientCreator, review.Matcher(c.routes), c.reviewSvc)
+	if auditorErr != nil {
+		return auditorErr
+	}
+
+	report, auditErr := auditor.Audit(ctx, *installationID)
+	if auditErr != nil {
+		return auditErr
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		w = file
+	}
+
+	return report.Write(w, *format)
+}
+
+// scheduledAuditHandler audits the repositories of the installation of the event, logs the report and returns it,
+// so it is kept by the logs and the destinations of the asynchronous invocation.
+func scheduledAuditHandler(auditor audit.Auditor) func(ctx context.Context, event auditEvent) (string, error) {
+	return func(ctx context.Context, event auditEvent) (string, error) {
+		format := event.Format
+		if format == "" {
+			format = audit.FormatJSON
+		}
+
+		report, auditErr := auditor.Audit(ctx, event.InstallationID)
+		if auditErr != nil {
+			return "", auditErr
+		}
+
+		var output strings.Builder
+		if err := report.Write(&output, format); err != nil {
+			return "", err
+		}
+
+		zerolog.Ctx(ctx).Info().Int64("installation_id", event.InstallationID).Msg(output.String())
+		return output.String(), nil
+	}
+}
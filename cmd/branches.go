@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/prhandler"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+)
+
+const reviewBranchesCommand = "review-branches"
+
+// reviewBranchesEvent is the input of the scheduled review of the default branches, e.g. the constant input of an
+// EventBridge schedule.
+type reviewBranchesEvent struct {
+	InstallationID int64 `json:"installation_id"`
+}
+
+// runReviewBranchesCommand reviews all the files of the default branches of the repositories of an installation whose
+// full review is due from the command line, e.g. from a cron job next to the server.
+func runReviewBranchesCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet(reviewBranchesCommand, flag.ContinueOnError)
+	installationID := flags.Int64("installation", 0, "ID of the installation whose default branches are reviewed")
+	path := flags.String("bundle", getEnv(bundlePathEnv, bundlePath), "path of the policy bundle")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return err
+	}
+
+	if *installationID == 0 {
+		return errors.New("installation is required")
+	}
+
+	c, setupErr := setup(ctx, *path)
+	if setupErr != nil {
+		return setupErr
+	}
+
+	return newBranchReviewer(c).ReviewBranches(ctx, *installationID)
+}
+
+// scheduledReviewBranchesHandler reviews the default branches of the repositories of the installation of the event.
+func scheduledReviewBranchesHandler(r prhandler.BranchReviewer) func(context.Context, reviewBranchesEvent) error {
+	return func(ctx context.Context, event reviewBranchesEvent) error {
+		return r.ReviewBranches(ctx, event.InstallationID)
+	}
+}
+
+// newBranchReviewer reviews the default branches with the same routes and policies as the webhook handler.
+func newBranchReviewer(c *components) prhandler.BranchReviewer {
+	return prhandler.NewBranchReviewer(c.clientCreator, review.Matcher(c.routes), c.reviewSvc)
+}
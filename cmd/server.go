@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/server"
+	"github.com/palantir/go-githubapp/githubapp"
+)
+
+const (
+	serveCommand          = "serve"
+	defaultServerAddress  = ":8080"
+	serverAddressEnv      = "GITHUB_APP_SERVER_ADDRESS"
+	tlsCertFileEnv        = "GITHUB_APP_TLS_CERT_FILE"
+	tlsKeyFileEnv         = "GITHUB_APP_TLS_KEY_FILE"
+	shutdownTimeoutEnv    = "GITHUB_APP_SHUTDOWN_TIMEOUT"
+	bundlePathEnv         = "GITHUB_APP_BUNDLE_PATH"
+	defaultShutdownPeriod = 30 * time.Second
+)
+
+// runServeCommand serves the webhook handler as a standalone HTTP server, e.g. on a VM or in a container, until it
+// receives SIGTERM or SIGINT. Every flag defaults to its env, so the server can be configured either way.
+func runServeCommand(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet(serveCommand, flag.ContinueOnError)
+	addr := flags.String("address", getEnv(serverAddressEnv, defaultServerAddress), "address the server listens on")
+	certFile := flags.String("tls-cert", os.Getenv(tlsCertFileEnv), "certificate file, serves HTTPS when set")
+	keyFile := flags.String("tls-key", os.Getenv(tlsKeyFileEnv), "private key file of the certificate")
+	path := flags.String("bundle", getEnv(bundlePathEnv, bundlePath), "path of the policy bundle")
+	shutdownTimeout := flags.Duration(
+		"shutdown-timeout",
+		defaultShutdownPeriod,
+		"how long the in-flight reviews are waited for on shutdown",
+	)
+
+	if timeout := os.Getenv(shutdownTimeoutEnv); timeout != "" {
+		if err := flags.Set("shutdown-timeout", timeout); err != nil {
+			return fmt.Errorf("invalid %s: %w", shutdownTimeoutEnv, err)
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+
+		return err
+	}
+
+	if (*certFile == "") != (*keyFile == "") {
+		return errors.New("tls-cert and tls-key must be set together")
+	}
+
+	c, setupErr := setup(ctx, *path)
+	if setupErr != nil {
+		return setupErr
+	}
+
+	webhookHandler, handlerErr := newWebhookHandler(ctx, c)
+	if handlerErr != nil {
+		return handlerErr
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(githubapp.DefaultWebhookRoute, webhookHandler)
+
+	opts := []server.Option{server.WithShutdownTimeout(*shutdownTimeout)}
+	if *certFile != "" {
+		opts = append(opts, server.WithTLS(*certFile, *keyFile))
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	return server.New(*addr, mux, opts...).Run(ctx)
+}
+
+// getEnv returns the value of the env, or the fallback when it is not set.
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
@@ -5,8 +5,11 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/palantir/go-githubapp/githubapp"
@@ -20,6 +23,16 @@ type SecretsManagerClient interface {
 	) (*secretsmanager.GetSecretValueOutput, error)
 }
 
+// RouteConfig maps glob patterns, and optionally detected file types, to the policy query used to review the matching
+// files. Envelope opts the policies of the route into the documents wrapped in the context of the file.
+type RouteConfig struct {
+	Name     string              `json:"name"`
+	Query    string              `json:"query"`
+	Patterns []string            `json:"patterns"`
+	Types    []reviewer.FileType `json:"types,omitempty"`
+	Envelope bool                `json:"envelope,omitempty"`
+}
+
 type Config struct {
 	V3ApiURL      string `json:"v3ApiUrl"`
 	IntegrationID int64  `json:"integrationId"`
@@ -45,15 +58,39 @@ func GetAppConfigFromSecret(
 		return nil, fmt.Errorf("failed to unmarshal secret: %w", jsonErr)
 	}
 
-	privateKey, decodeErr := base64.StdEncoding.DecodeString(cfg.PrivateKey)
+	return cfg.toAppConfig()
+}
+
+// GetAppConfigFromEnv retrieves the application configuration from the GITHUB_V3_API_URL, GITHUB_APP_INTEGRATION_ID,
+// GITHUB_APP_WEBHOOK_SECRET and GITHUB_APP_PRIVATE_KEY envs looked up by getenv, the private key being base64 encoded
+// as in the secret. It is used where the secret is not available, e.g. outside of AWS.
+func GetAppConfigFromEnv(getenv func(string) string) (*githubapp.Config, error) {
+	integrationID, parseErr := strconv.ParseInt(getenv("GITHUB_APP_INTEGRATION_ID"), 10, 64)
+	if parseErr != nil {
+		return nil, fmt.Errorf("failed to parse integration id: %w", parseErr)
+	}
+
+	cfg := &Config{
+		V3ApiURL:      getenv("GITHUB_V3_API_URL"),
+		IntegrationID: integrationID,
+		WebhookSecret: getenv("GITHUB_APP_WEBHOOK_SECRET"),
+		PrivateKey:    getenv("GITHUB_APP_PRIVATE_KEY"),
+	}
+
+	return cfg.toAppConfig()
+}
+
+// toAppConfig converts the config to a githubapp.Config, decoding its base64 encoded private key.
+func (c *Config) toAppConfig() (*githubapp.Config, error) {
+	privateKey, decodeErr := base64.StdEncoding.DecodeString(c.PrivateKey)
 	if decodeErr != nil {
 		return nil, fmt.Errorf("failed to decode private key: %w", decodeErr)
 	}
 
 	appConfig := new(githubapp.Config)
-	appConfig.V3APIURL = cfg.V3ApiURL
-	appConfig.App.IntegrationID = cfg.IntegrationID
-	appConfig.App.WebhookSecret = cfg.WebhookSecret
+	appConfig.V3APIURL = c.V3ApiURL
+	appConfig.App.IntegrationID = c.IntegrationID
+	appConfig.App.WebhookSecret = c.WebhookSecret
 	appConfig.App.PrivateKey = string(privateKey)
 
 	return appConfig, nil
@@ -72,3 +109,26 @@ func GetPatternsFromCSV(csv string) []string {
 
 	return patterns
 }
+
+// GetRoutesFromJSON retrieves the route configs from a JSON array string.
+// Every route requires a name, a query and at least one pattern, and only detectable file types.
+func GetRoutesFromJSON(str string) ([]RouteConfig, error) {
+	routes := make([]RouteConfig, 0)
+	if err := json.Unmarshal([]byte(str), &routes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal routes: %w", err)
+	}
+
+	for idx := range routes {
+		if routes[idx].Name == "" || routes[idx].Query == "" || len(routes[idx].Patterns) == 0 {
+			return nil, fmt.Errorf("route %d requires a name, a query and at least one pattern", idx)
+		}
+
+		for _, fileType := range routes[idx].Types {
+			if !slices.Contains(reviewer.FileTypes, fileType) {
+				return nil, fmt.Errorf("route %d has unknown file type %q", idx, fileType)
+			}
+		}
+	}
+
+	return routes, nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 	"github.com/palantir/go-githubapp/githubapp"
@@ -93,6 +94,54 @@ func TestGetAppConfigFromSecret(t *testing.T) {
 	}
 }
 
+func TestGetAppConfigFromEnv(t *testing.T) {
+	cases := map[string]struct {
+		envs     map[string]string
+		expected *githubapp.Config
+		errMsg   *string
+	}{
+		"get app config from envs": {
+			envs: map[string]string{
+				"GITHUB_V3_API_URL":         "https://api.github.com/",
+				"GITHUB_APP_INTEGRATION_ID": "123456",
+				"GITHUB_APP_WEBHOOK_SECRET": "secret",
+				"GITHUB_APP_PRIVATE_KEY":    "cHJpdmF0ZV9rZXk=",
+			},
+			expected: getGithubAppConfig(
+				"https://api.github.com/",
+				"secret",
+				"private_key",
+				123456,
+			),
+		},
+		"fail to parse integration id should return error": {
+			envs:   map[string]string{"GITHUB_APP_INTEGRATION_ID": "app"},
+			errMsg: aws.String("failed to parse integration id"),
+		},
+		"fail to decode private key should return error": {
+			envs:   map[string]string{"GITHUB_APP_INTEGRATION_ID": "123456", "GITHUB_APP_PRIVATE_KEY": "!@"},
+			errMsg: aws.String("failed to decode private key: illegal base64 data at input byte 0"),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			appConfig, err := GetAppConfigFromEnv(func(key string) string {
+				return tc.envs[key]
+			})
+
+			if tc.errMsg != nil {
+				a.Contains(err.Error(), *tc.errMsg)
+				return
+			}
+
+			a.NoError(err)
+			a.EqualValues(tc.expected, appConfig)
+		})
+	}
+}
+
 func TestGetPatternsFromCSV(t *testing.T) {
 	cases := map[string]struct {
 		csv      string
@@ -116,6 +165,61 @@ func TestGetPatternsFromCSV(t *testing.T) {
 	}
 }
 
+func TestGetRoutesFromJSON(t *testing.T) {
+	cases := map[string]struct {
+		json     string
+		expected []RouteConfig
+		errMsg   *string
+	}{
+		"get routes from json": {
+			json: `[
+  {"name": "cfn", "query": "data.reviewer.cfn", "patterns": ["stack/**/*.yaml"]},
+  {"name": "k8s", "query": "data.reviewer.k8s", "patterns": ["k8s/**/*.yaml", "charts/**/*.yaml"]},
+  {"name": "manifests", "query": "data.reviewer.k8s", "patterns": ["**/*.yaml"], "types": ["kubernetes"]},
+  {"name": "prod", "query": "data.reviewer.prod", "patterns": ["stack/prod/**"], "envelope": true}
+]`,
+			expected: []RouteConfig{
+				{Name: "cfn", Query: "data.reviewer.cfn", Patterns: []string{"stack/**/*.yaml"}},
+				{Name: "k8s", Query: "data.reviewer.k8s", Patterns: []string{"k8s/**/*.yaml", "charts/**/*.yaml"}},
+				{
+					Name:     "manifests",
+					Query:    "data.reviewer.k8s",
+					Patterns: []string{"**/*.yaml"},
+					Types:    []reviewer.FileType{reviewer.FileTypeKubernetes},
+				},
+				{Name: "prod", Query: "data.reviewer.prod", Patterns: []string{"stack/prod/**"}, Envelope: true},
+			},
+		},
+		"fail to unmarshal routes should return error": {
+			json:   "{",
+			errMsg: aws.String("failed to unmarshal routes"),
+		},
+		"route without patterns should return error": {
+			json:   `[{"name": "cfn", "query": "data.reviewer.cfn"}]`,
+			errMsg: aws.String("route 0 requires a name, a query and at least one pattern"),
+		},
+		"route with unknown file type should return error": {
+			json:   `[{"name": "cfn", "query": "data.reviewer.cfn", "patterns": ["**"], "types": ["helm"]}]`,
+			errMsg: aws.String(`route 0 has unknown file type "helm"`),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			routes, err := GetRoutesFromJSON(tc.json)
+
+			if tc.errMsg != nil {
+				a.Contains(err.Error(), *tc.errMsg)
+				return
+			}
+
+			a.NoError(err)
+			a.Equal(tc.expected, routes)
+		})
+	}
+}
+
 func getGithubAppConfig(v3ApiURL, webhookSecret, privateKey string, integrationID int64) *githubapp.Config {
 	cfg := new(githubapp.Config)
 	cfg.V3APIURL = v3ApiURL
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/reader"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+)
+
+const statusUnchanged = "unchanged"
+
+// Auditor reviews the files of the default branches of all the repositories an installation can access.
+type Auditor interface {
+	Audit(ctx context.Context, installationID int64) (*Report, error)
+}
+
+type auditor struct {
+	clientCreator githubapp.ClientCreator
+	matcher       filematch.Matcher
+	reviewSvc     review.Service
+}
+
+// Audit enumerates the repositories of the installation and reviews the files of their default branches which are
+// matched by the matcher and by the repository configuration. A repository which could not be reviewed is reported
+// with its error rather than failing the whole audit.
+func (a *auditor) Audit(ctx context.Context, installationID int64) (*Report, error) {
+	client, clientErr := a.clientCreator.NewInstallationClient(installationID)
+	if clientErr != nil {
+		return nil, clientErr
+	}
+
+	repos, reposErr := reader.ListGitHubRepositories(ctx, client)
+	if reposErr != nil {
+		return nil, reposErr
+	}
+
+	report := &Report{InstallationID: installationID, Repositories: make([]RepositoryReport, 0, len(repos))}
+	for _, repo := range repos {
+		if repo.GetArchived() {
+			continue
+		}
+
+		zerolog.Ctx(ctx).Debug().Msgf("auditing %s", repo.GetFullName())
+		repoReport, err := a.auditRepository(ctx, client, repo)
+		if err != nil {
+			repoReport.Error = err.Error()
+		}
+
+		report.Repositories = append(report.Repositories, *repoReport)
+	}
+
+	return report, nil
+}
+
+// auditRepository reviews the matching files of the head commit of the default branch of the repository.
+func (a *auditor) auditRepository(
+	ctx context.Context,
+	client *github.Client,
+	repo *github.Repository,
+) (*RepositoryReport, error) {
+	owner, name, branch := repo.GetOwner().GetLogin(), repo.GetName(), repo.GetDefaultBranch()
+	report := &RepositoryReport{
+		Repository: repo.GetFullName(),
+		Branch:     branch,
+		Violations: make([]presentation.FileViolation, 0),
+	}
+
+	cfg, cfgErr := repoconfig.Load(ctx, client, owner, name, branch)
+	if cfgErr != nil {
+		return report, cfgErr
+	}
+
+	head, _, branchErr := client.Repositories.GetBranch(ctx, owner, name, branch, 1)
+	if branchErr != nil {
+		return report, branchErr
+	}
+
+	report.SHA = head.GetCommit().GetSHA()
+	names, truncated, filesErr := reader.ListGitHubFiles(ctx, client, owner, name, report.SHA)
+	if filesErr != nil {
+		return report, filesErr
+	}
+
+	if truncated {
+		zerolog.Ctx(ctx).Warn().Msgf("the tree of %s is truncated, only part of its files are audited", report.Repository)
+	}
+
+	files := make([]review.ChangedFile, 0)
+	for _, file := range names {
+		if a.matcher.Match(file, statusUnchanged) && cfg.Matches(file, statusUnchanged) {
+			files = append(files, review.ChangedFile{Name: file, Status: statusUnchanged})
+		}
+	}
+
+	report.Files = len(files)
+	if len(files) == 0 {
+		report.Outcome = presentation.Outcome(nil)
+		return report, nil
+	}
+
+	// The files of the branch are not changed, hence not reviewed as a changeset.
+	results, reviewErr := a.reviewSvc.Review(
+		ctx,
+		reader.ReadGitHubFile(client, owner, name, report.SHA),
+		files,
+		review.WithEnvelope(reviewer.Envelope{Repository: report.Repository, BaseRef: branch, HeadSHA: report.SHA}),
+		review.WithoutChangeset(),
+	)
+	if reviewErr != nil {
+		return report, reviewErr
+	}
+
+	results = cfg.Apply(results)
+	report.Outcome = presentation.Outcome(results)
+	report.Violations = presentation.OpenViolations(results)
+	for idx := range results {
+		if results[idx].Error != nil {
+			report.Errors = append(report.Errors, FileError{File: results[idx].File, Error: results[idx].Error.Error()})
+		}
+	}
+
+	return report, nil
+}
+
+// New returns an Auditor reviewing the files matched by the matcher with the review service.
+func New(clientCreator githubapp.ClientCreator, matcher filematch.Matcher, reviewSvc review.Service) (Auditor, error) {
+	if clientCreator == nil || matcher == nil || reviewSvc == nil {
+		return nil, errors.New("client creator, matcher and review service are required")
+	}
+
+	return &auditor{clientCreator: clientCreator, matcher: matcher, reviewSvc: reviewSvc}, nil
+}
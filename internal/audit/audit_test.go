@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockClientCreator struct {
+	githubapp.ClientCreator
+	client    *github.Client
+	clientErr error
+}
+
+func (m *mockClientCreator) NewInstallationClient(_ int64) (*github.Client, error) {
+	if m.clientErr != nil {
+		return nil, m.clientErr
+	}
+
+	return m.client, nil
+}
+
+type mockReviewSvc struct{}
+
+func (m *mockReviewSvc) Review(
+	_ context.Context,
+	_ review.ReadFileFunc,
+	files []review.ChangedFile,
+	_ ...review.Option,
+) ([]review.Result, error) {
+	res := make([]review.Result, 0)
+	for _, file := range files {
+		if strings.Contains(file.Name, "invalid") {
+			res = append(res, review.Result{File: file.Name, Error: errors.New("invalid file")})
+			continue
+		}
+
+		res = append(res, review.Result{
+			File: file.Name,
+			Decision: &reviewer.Decision{Violations: []reviewer.Violation{
+				{RuleID: "rule_1", Severity: reviewer.SeverityError, Message: "violation_1", Package: "reviewer.cfn"},
+			}},
+		})
+	}
+
+	return res, nil
+}
+
+func TestAuditor_Audit(t *testing.T) {
+	a := assert.New(t)
+	client := github.NewClient(mock.NewMockedHTTPClient(
+		mock.WithRequestMatch(
+			mock.GetInstallationRepositories,
+			github.ListRepositories{Repositories: []*github.Repository{
+				getRepo("app", false),
+				getRepo("misconfigured", false),
+				getRepo("archived", true),
+			}},
+		),
+		mock.WithRequestMatchHandler(
+			mock.GetReposContentsByOwnerByRepoByPath,
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if strings.Contains(req.URL.Path, "misconfigured") {
+					_, _ = w.Write(mock.MustMarshal(github.RepositoryContent{Content: github.String("outputs: [email]")}))
+					return
+				}
+
+				mock.WriteError(w, http.StatusNotFound, "not found")
+			}),
+		),
+		mock.WithRequestMatch(
+			mock.GetReposBranchesByOwnerByRepoByBranch,
+			github.Branch{Commit: &github.RepositoryCommit{SHA: github.String("abcdef")}},
+		),
+		mock.WithRequestMatch(
+			mock.GetReposGitTreesByOwnerByRepoByTreeSha,
+			github.Tree{Entries: []*github.TreeEntry{
+				{Path: github.String("stack/app.yaml"), Type: github.String("blob")},
+				{Path: github.String("stack/invalid.yaml"), Type: github.String("blob")},
+				{Path: github.String("docs/README.md"), Type: github.String("blob")},
+			}},
+		),
+	))
+
+	auditor, _ := New(&mockClientCreator{client: client}, filematch.New([]string{"stack/**/*.yaml"}), new(mockReviewSvc))
+	report, err := auditor.Audit(context.TODO(), 12345678)
+
+	a.Nil(err)
+	a.Equal(&Report{
+		InstallationID: 12345678,
+		Repositories: []RepositoryReport{
+			{
+				Repository: "owner/app",
+				Branch:     "main",
+				SHA:        "abcdef",
+				Files:      2,
+				Outcome:    "failed",
+				Violations: []presentation.FileViolation{{
+					File: "stack/app.yaml",
+					Violation: &reviewer.Violation{
+						RuleID:   "rule_1",
+						Severity: reviewer.SeverityError,
+						Message:  "violation_1",
+						Package:  "reviewer.cfn",
+					},
+				}},
+				Errors: []FileError{{File: "stack/invalid.yaml", Error: "invalid file"}},
+			},
+			{
+				Repository: "owner/misconfigured",
+				Branch:     "main",
+				Violations: make([]presentation.FileViolation, 0),
+				Error:      report.Repositories[1].Error,
+			},
+		},
+	}, report)
+	a.Contains(report.Repositories[1].Error, "outputs")
+}
+
+func TestAuditor_Audit_ClientError(t *testing.T) {
+	a := assert.New(t)
+	auditor, _ := New(
+		&mockClientCreator{clientErr: errors.New("client error")},
+		filematch.New([]string{"stack/**/*.yaml"}),
+		new(mockReviewSvc),
+	)
+
+	report, err := auditor.Audit(context.TODO(), 12345678)
+
+	a.Nil(report)
+	a.EqualError(err, "client error")
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, filematch.New(nil), new(mockReviewSvc))
+	assert.EqualError(t, err, "client creator, matcher and review service are required")
+}
+
+func getRepo(name string, archived bool) *github.Repository {
+	return &github.Repository{
+		Name:          github.String(name),
+		FullName:      github.String("owner/" + name),
+		Owner:         &github.User{Login: github.String("owner")},
+		DefaultBranch: github.String("main"),
+		Archived:      github.Bool(archived),
+	}
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+	FormatCSV      = "csv"
+)
+
+// Report is the consolidated outcome of the audit of the repositories of an installation.
+type Report struct {
+	InstallationID int64              `json:"installation_id"`
+	Repositories   []RepositoryReport `json:"repositories"`
+}
+
+// RepositoryReport is the outcome of the audit of the default branch of a repository. Error is set if the repository
+// could not be reviewed, e.g. because of an invalid configuration.
+type RepositoryReport struct {
+	Repository string                       `json:"repository"`
+	Branch     string                       `json:"branch"`
+	SHA        string                       `json:"sha"`
+	Files      int                          `json:"files"`
+	Outcome    string                       `json:"outcome"`
+	Violations []presentation.FileViolation `json:"violations"`
+	Errors     []FileError                  `json:"errors,omitempty"`
+	Error      string                       `json:"error,omitempty"`
+}
+
+// FileError is a file which failed to review.
+type FileError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// Write writes the report to w in the given format, one of json, markdown and csv.
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case FormatMarkdown:
+		_, err := io.WriteString(w, r.markdown())
+		return err
+	case FormatCSV:
+		return r.writeCSV(w)
+	}
+
+	return fmt.Errorf("unsupported report format %s, must be one of %s, %s, %s",
+		format, FormatJSON, FormatMarkdown, FormatCSV)
+}
+
+// markdown renders a summary table of the repositories followed by the violations and errors of each of them.
+func (r *Report) markdown() string {
+	var output strings.Builder
+	fmt.Fprintf(&output, "# OPA Review audit of installation %d\n\n", r.InstallationID)
+	output.WriteString("| Repository | Branch | Files | Violations | Outcome |\n")
+	output.WriteString("| --- | --- | --- | --- | --- |\n")
+	for idx := range r.Repositories {
+		repo := &r.Repositories[idx]
+		outcome := repo.Outcome
+		if repo.Error != "" {
+			outcome = "review failed"
+		}
+
+		fmt.Fprintf(&output, "| %s | %s | %d | %d | %s |\n",
+			repo.Repository, repo.Branch, repo.Files, len(repo.Violations), outcome)
+	}
+
+	for idx := range r.Repositories {
+		repo := &r.Repositories[idx]
+		if repo.Error == "" && len(repo.Violations) == 0 && len(repo.Errors) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&output, "\n## %s\n\n", repo.Repository)
+		if repo.Error != "" {
+			fmt.Fprintf(&output, "Review failed: %s\n", repo.Error)
+			continue
+		}
+
+		for vIdx := range repo.Violations {
+			output.WriteString(presentation.ViolationRow(&repo.Violations[vIdx]) + "\n")
+		}
+
+		for _, fileErr := range repo.Errors {
+			fmt.Fprintf(&output, "* %s: %s\n", fileErr.File, fileErr.Error)
+		}
+	}
+
+	return output.String()
+}
+
+// writeCSV writes a row per violation, file error and repository which could not be reviewed.
+func (r *Report) writeCSV(w io.Writer) error {
+	rows := [][]string{{
+		"repository", "branch", "sha", "file", "package", "rule", "severity", "message", "resource", "line", "error",
+	}}
+
+	for idx := range r.Repositories {
+		repo := &r.Repositories[idx]
+		if repo.Error != "" {
+			rows = append(rows, csvRow(repo, "", "", "", "", "", "", "", repo.Error))
+			continue
+		}
+
+		for _, violation := range repo.Violations {
+			v := violation.Violation
+			rows = append(rows, csvRow(repo, violation.File, v.Package, v.RuleID, string(v.Severity), v.Message,
+				v.ResourceID, strconv.Itoa(v.Location.StartLine), ""))
+		}
+
+		for _, fileErr := range repo.Errors {
+			rows = append(rows, csvRow(repo, fileErr.File, "", "", "", "", "", "", fileErr.Error))
+		}
+	}
+
+	return csv.NewWriter(w).WriteAll(rows)
+}
+
+// csvRow prefixes the columns with the repository, branch and commit of the repository report.
+func csvRow(repo *RepositoryReport, columns ...string) []string {
+	return append([]string{repo.Repository, repo.Branch, repo.SHA}, columns...)
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport_Write(t *testing.T) {
+	report := &Report{
+		InstallationID: 12345678,
+		Repositories: []RepositoryReport{
+			{
+				Repository: "owner/app",
+				Branch:     "main",
+				SHA:        "abcdef",
+				Files:      2,
+				Outcome:    "failed",
+				Violations: []presentation.FileViolation{{
+					File: "stack/app.yaml",
+					Violation: &reviewer.Violation{
+						RuleID:   "rule_1",
+						Severity: reviewer.SeverityError,
+						Message:  "violation_1",
+						Package:  "reviewer.cfn",
+						Location: reviewer.Location{StartLine: 3},
+					},
+				}},
+				Errors: []FileError{{File: "stack/invalid.yaml", Error: "invalid file"}},
+			},
+			{Repository: "owner/clean", Branch: "main", SHA: "12345", Files: 1, Outcome: "passed"},
+			{Repository: "owner/misconfigured", Branch: "main", Error: "invalid configuration"},
+		},
+	}
+
+	cases := map[string]struct {
+		format      string
+		expected    string
+		expectedErr string
+	}{
+		"json": {
+			format:   FormatJSON,
+			expected: "{\n  \"installation_id\": 12345678,\n  \"repositories\": [\n    {\n      \"repository\": \"owner/app\",",
+		},
+		"markdown": {
+			format: FormatMarkdown,
+			expected: strings.Join([]string{
+				"# OPA Review audit of installation 12345678",
+				"",
+				"| Repository | Branch | Files | Violations | Outcome |",
+				"| --- | --- | --- | --- | --- |",
+				"| owner/app | main | 2 | 1 | failed |",
+				"| owner/clean | main | 1 | 0 | passed |",
+				"| owner/misconfigured | main | 0 | 0 | review failed |",
+				"",
+				"## owner/app",
+				"",
+				"* stack/app.yaml: [error] rule_1: violation_1 (line 3)",
+				"* stack/invalid.yaml: invalid file",
+				"",
+				"## owner/misconfigured",
+				"",
+				"Review failed: invalid configuration",
+				"",
+			}, "\n"),
+		},
+		"csv": {
+			format: FormatCSV,
+			expected: strings.Join([]string{
+				"repository,branch,sha,file,package,rule,severity,message,resource,line,error",
+				"owner/app,main,abcdef,stack/app.yaml,reviewer.cfn,rule_1,error,violation_1,,3,",
+				"owner/app,main,abcdef,stack/invalid.yaml,,,,,,,invalid file",
+				"owner/misconfigured,main,,,,,,,,,invalid configuration",
+				"",
+			}, "\n"),
+		},
+		"unsupported format": {
+			format:      "xml",
+			expectedErr: "unsupported report format xml, must be one of json, markdown, csv",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var output strings.Builder
+
+			err := report.Write(&output, tc.format)
+
+			if tc.expectedErr != "" {
+				a.EqualError(err, tc.expectedErr)
+				return
+			}
+
+			a.Nil(err)
+			if tc.format == FormatJSON {
+				a.True(strings.HasPrefix(output.String(), tc.expected))
+				return
+			}
+
+			a.Equal(tc.expected, output.String())
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package filematch
+
+import (
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+const (
+	negationPrefix  = "!"
+	statusSeparator = ":"
+	statusDelimiter = "|"
+)
+
+// statuses are the statuses of the files changed by a pull request, see the GitHub API.
+var statuses = map[string]bool{
+	"added":     true,
+	"removed":   true,
+	"modified":  true,
+	"renamed":   true,
+	"copied":    true,
+	"changed":   true,
+	"unchanged": true,
+}
+
+// Matcher decides whether a file, given its name and change status, is matched.
+type Matcher interface {
+	Match(name, status string) bool
+}
+
+// Rules is an ordered list of gitignore-like matching rules, where the last rule matching a file decides whether the
+// file is matched, and a file matching no rule is not matched. Every rule has the following syntax:
+//
+//	[!][<status>[|<status>]:]<glob>
+//
+// A rule prefixed with ! excludes the files it matches, and a rule qualified with statuses only matches the files with
+// one of these statuses, e.g. !removed:** excludes all the removed files. Files of unknown status are never matched
+// by qualified rules.
+type Rules []rule
+
+type rule struct {
+	pattern  string
+	negate   bool
+	statuses []string
+}
+
+// New parses the given patterns into ordered matching rules.
+func New(patterns []string) Rules {
+	rules := make(Rules, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, parseRule(pattern))
+	}
+
+	return rules
+}
+
+// Match reports whether the last rule matching the file includes it.
+func (r Rules) Match(name, status string) bool {
+	matched := false
+	for idx := range r {
+		if r[idx].match(name, status) {
+			matched = !r[idx].negate
+		}
+	}
+
+	return matched
+}
+
+// Any matches the files matched by any of its matchers.
+type Any []Matcher
+
+func (a Any) Match(name, status string) bool {
+	for _, matcher := range a {
+		if matcher.Match(name, status) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate reports a syntax error in the glob of a pattern.
+func Validate(pattern string) error {
+	glob := parseRule(pattern).pattern
+
+	// Syntax errors are only found while matching, so the glob is matched against itself.
+	_, err := doublestar.Match(glob, glob)
+	return err
+}
+
+func parseRule(pattern string) rule {
+	r := rule{pattern: pattern}
+	if strings.HasPrefix(r.pattern, negationPrefix) {
+		r.negate = true
+		r.pattern = strings.TrimPrefix(r.pattern, negationPrefix)
+	}
+
+	qualifier, glob, found := strings.Cut(r.pattern, statusSeparator)
+	if !found {
+		return r
+	}
+
+	qualified := strings.Split(qualifier, statusDelimiter)
+	for _, status := range qualified {
+		if !statuses[status] {
+			return r
+		}
+	}
+
+	r.pattern = glob
+	r.statuses = qualified
+	return r
+}
+
+func (r *rule) match(name, status string) bool {
+	if len(r.statuses) > 0 && !contains(r.statuses, status) {
+		return false
+	}
+
+	matched, _ := doublestar.PathMatch(r.pattern, name)
+	return matched
+}
+
+func contains(s []string, item string) bool {
+	for idx := range s {
+		if s[idx] == item {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+package filematch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRules_Match(t *testing.T) {
+	cases := map[string]struct {
+		patterns []string
+		name     string
+		status   string
+		expected bool
+	}{
+		"match positive pattern": {
+			patterns: []string{"k8s/*.yaml", "stack/**/*.yaml"},
+			name:     "stack/app/file_1.yaml",
+			status:   "modified",
+			expected: true,
+		},
+		"not match without patterns": {
+			name: "stack/file_1.yaml",
+		},
+		"exclude with negated pattern": {
+			patterns: []string{"stack/**/*.yaml", "!stack/**/fixtures/**"},
+			name:     "stack/app/fixtures/file_1.yaml",
+		},
+		"last matching rule wins": {
+			patterns: []string{"stack/**/*.yaml", "!stack/**/fixtures/**", "stack/**/fixtures/keep.yaml"},
+			name:     "stack/app/fixtures/keep.yaml",
+			expected: true,
+		},
+		"exclude removed files": {
+			patterns: []string{"stack/**/*.yaml", "!removed:**"},
+			name:     "stack/file_1.yaml",
+			status:   "removed",
+		},
+		"match one of the statuses": {
+			patterns: []string{"added|modified:stack/**/*.yaml"},
+			name:     "stack/file_1.yaml",
+			status:   "modified",
+			expected: true,
+		},
+		"not match other statuses": {
+			patterns: []string{"added|modified:stack/**/*.yaml"},
+			name:     "stack/file_1.yaml",
+			status:   "renamed",
+		},
+		"not match unknown status with qualified rule": {
+			patterns: []string{"modified:stack/**/*.yaml"},
+			name:     "stack/file_1.yaml",
+		},
+		"treat unknown qualifier as part of the glob": {
+			patterns: []string{"stack/c:/*.yaml"},
+			name:     "stack/c:/file_1.yaml",
+			expected: true,
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, New(tc.patterns).Match(tc.name, tc.status))
+		})
+	}
+}
+
+func TestAny_Match(t *testing.T) {
+	a := assert.New(t)
+	matcher := Any{New([]string{"stack/**", "!stack/fixtures/**"}), New([]string{"stack/fixtures/*.yaml"})}
+
+	a.True(matcher.Match("stack/fixtures/file_1.yaml", "added"))
+	a.False(matcher.Match("stack/fixtures/file_1.json", "added"))
+	a.False(Any{}.Match("stack/file_1.yaml", "added"))
+}
+
+func TestValidate(t *testing.T) {
+	a := assert.New(t)
+
+	a.NoError(Validate("!removed:stack/**/*.yaml"))
+	a.Error(Validate("!removed:stack/[.yaml"))
+}
@@ -0,0 +1,131 @@
+package presentation
+
+import (
+	"fmt"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+)
+
+const (
+	ConclusionSuccess = "success"
+	ConclusionFailure = "failure"
+	ConclusionNeutral = "neutral"
+)
+
+// annotationLevels maps the violation severities to the check run annotation levels.
+var annotationLevels = map[reviewer.Severity]string{
+	reviewer.SeverityError:   "failure",
+	reviewer.SeverityWarning: "warning",
+	reviewer.SeverityInfo:    "notice",
+}
+
+// Conclusion returns the check run conclusion of the given results. The check run fails if any file is not allowed
+// or could not be reviewed, and is neutral if warnings were found.
+func Conclusion(results []review.Result) string {
+	for idx := range results {
+		if results[idx].Error != nil {
+			return ConclusionFailure
+		}
+	}
+
+	switch Outcome(results) {
+	case outcomeFailed:
+		return ConclusionFailure
+	case outcomePassedWithWarnings:
+		return ConclusionNeutral
+	default:
+		return ConclusionSuccess
+	}
+}
+
+// Title returns the check run title summarising the number of reviewed files and violations, along with the number of
+// new and fixed violations when the files were reviewed against their base content.
+func Title(results []review.Result) string {
+	violations, added, fixed, errors := 0, 0, 0, 0
+	diff := false
+	for idx := range results {
+		if results[idx].Error != nil {
+			errors++
+			continue
+		}
+
+		decision := results[idx].Decision
+		violations += len(decision.Violations)
+		for vIdx := range decision.Violations {
+			if decision.Violations[vIdx].Change == reviewer.ViolationNew {
+				added++
+			}
+		}
+
+		diff = diff || decision.Fixed != nil
+		fixed += len(decision.Fixed)
+	}
+
+	title := fmt.Sprintf("%d files reviewed, %d violations found", len(results), violations)
+	if diff {
+		title = fmt.Sprintf("%s (%d new, %d fixed)", title, added, fixed)
+	}
+
+	if errors > 0 {
+		title = fmt.Sprintf("%s, %d files failed to review", title, errors)
+	}
+
+	return title
+}
+
+// Annotations converts the violations of the given results into check run annotations on the offending lines.
+// Violations without a location annotate the first line of the file, the ones of the changeset and of the pull request
+// are only reported by the summary.
+func Annotations(results []review.Result) []*github.CheckRunAnnotation {
+	annotations := make([]*github.CheckRunAnnotation, 0)
+	for idx := range results {
+		if results[idx].Decision == nil || !results[idx].IsFile() {
+			continue
+		}
+
+		for vIdx := range results[idx].Decision.Violations {
+			annotations = append(annotations, annotation(results[idx].File, &results[idx].Decision.Violations[vIdx]))
+		}
+	}
+
+	return annotations
+}
+
+// annotation converts a violation into a check run annotation. Columns are only allowed by GitHub when the
+// annotation starts and ends on the same line.
+func annotation(file string, violation *reviewer.Violation) *github.CheckRunAnnotation {
+	location := violation.Location
+	startLine, endLine := max(location.StartLine, 1), max(location.EndLine, location.StartLine, 1)
+
+	level, ok := annotationLevels[violation.Severity]
+	if !ok {
+		level = annotationLevels[reviewer.SeverityInfo]
+	}
+
+	result := &github.CheckRunAnnotation{
+		Path:            github.String(file),
+		StartLine:       github.Int(startLine),
+		EndLine:         github.Int(endLine),
+		AnnotationLevel: github.String(level),
+		Title:           github.String(annotationTitle(violation)),
+		Message:         github.String(violation.Message),
+	}
+
+	if startLine == endLine && location.StartColumn > 0 && location.EndColumn >= location.StartColumn {
+		result.StartColumn = github.Int(location.StartColumn)
+		result.EndColumn = github.Int(location.EndColumn)
+	}
+
+	return result
+}
+
+// annotationTitle returns the rule of a violation, followed by its change against the base content if any.
+func annotationTitle(violation *reviewer.Violation) string {
+	if violation.Change == "" {
+		return violation.RuleID
+	}
+
+	return fmt.Sprintf("%s (%s)", violation.RuleID, violation.Change)
+}
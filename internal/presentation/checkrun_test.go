@@ -0,0 +1,177 @@
+package presentation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConclusion(t *testing.T) {
+	cases := map[string]struct {
+		results  []review.Result
+		expected string
+	}{
+		"no violations should succeed": {
+			results:  []review.Result{{File: "file-1", Decision: &reviewer.Decision{Allow: true}}},
+			expected: ConclusionSuccess,
+		},
+		"info violations should succeed": {
+			results:  []review.Result{{File: "file-1", Decision: decisionWith(true, reviewer.SeverityInfo)}},
+			expected: ConclusionSuccess,
+		},
+		"warning violations should be neutral": {
+			results:  []review.Result{{File: "file-1", Decision: decisionWith(true, reviewer.SeverityWarning)}},
+			expected: ConclusionNeutral,
+		},
+		"error violations should fail": {
+			results: []review.Result{
+				{File: "file-1", Decision: decisionWith(true, reviewer.SeverityWarning)},
+				{File: "file-2", Decision: decisionWith(false, reviewer.SeverityError)},
+			},
+			expected: ConclusionFailure,
+		},
+		"allowed error violations should be neutral": {
+			results:  []review.Result{{File: "file-1", Decision: decisionWith(true, reviewer.SeverityError)}},
+			expected: ConclusionNeutral,
+		},
+		"review errors should fail": {
+			results: []review.Result{
+				{File: "file-1", Decision: &reviewer.Decision{Allow: true}},
+				{File: "file-2", Error: errors.New("error_1")},
+			},
+			expected: ConclusionFailure,
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Conclusion(tc.results))
+		})
+	}
+}
+
+func TestTitle(t *testing.T) {
+	cases := map[string]struct {
+		results  []review.Result
+		expected string
+	}{
+		"count files and violations": {
+			results: []review.Result{
+				{File: "file-1", Decision: decisionWith(false, reviewer.SeverityError, reviewer.SeverityInfo)},
+				{File: "file-2", Decision: &reviewer.Decision{Allow: true}},
+			},
+			expected: "2 files reviewed, 2 violations found",
+		},
+		"count review errors": {
+			results: []review.Result{
+				{File: "file-1", Decision: decisionWith(true, reviewer.SeverityWarning)},
+				{File: "file-2", Error: errors.New("error_1")},
+			},
+			expected: "2 files reviewed, 1 violations found, 1 files failed to review",
+		},
+		"count new and fixed violations": {
+			results: []review.Result{
+				{
+					File: "file-1",
+					Decision: &reviewer.Decision{
+						Violations: []reviewer.Violation{
+							{RuleID: "rule_1", Change: reviewer.ViolationNew},
+							{RuleID: "rule_2", Change: reviewer.ViolationUnchanged},
+						},
+						Fixed: []reviewer.Violation{{RuleID: "rule_3", Change: reviewer.ViolationFixed}},
+					},
+				},
+				{File: "file-2", Decision: &reviewer.Decision{Allow: true, Fixed: []reviewer.Violation{}}},
+			},
+			expected: "2 files reviewed, 2 violations found (1 new, 1 fixed)",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Title(tc.results))
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	a := assert.New(t)
+	results := []review.Result{
+		{
+			File: "file-1",
+			Decision: &reviewer.Decision{
+				Violations: []reviewer.Violation{
+					{
+						RuleID:   "rule_1",
+						Severity: reviewer.SeverityError,
+						Message:  "violation_1",
+						Location: reviewer.Location{StartLine: 2, StartColumn: 3, EndLine: 2, EndColumn: 5},
+					},
+					{
+						RuleID:   "rule_2",
+						Severity: reviewer.SeverityWarning,
+						Message:  "violation_2",
+						Location: reviewer.Location{StartLine: 4, StartColumn: 3, EndLine: 6, EndColumn: 5},
+					},
+				},
+			},
+		},
+		{File: "file-2", Error: errors.New("error_1")},
+		{
+			Route: review.ChangesetRoute,
+			Decision: &reviewer.Decision{
+				Violations: []reviewer.Violation{{RuleID: "rule_4", Severity: reviewer.SeverityError}},
+			},
+		},
+		{
+			File: "file-3",
+			Decision: &reviewer.Decision{
+				Violations: []reviewer.Violation{
+					{RuleID: "rule_3", Severity: reviewer.SeverityInfo, Message: "violation_3", Change: reviewer.ViolationNew},
+				},
+			},
+		},
+	}
+
+	a.Equal([]*github.CheckRunAnnotation{
+		{
+			Path:            github.String("file-1"),
+			StartLine:       github.Int(2),
+			EndLine:         github.Int(2),
+			StartColumn:     github.Int(3),
+			EndColumn:       github.Int(5),
+			AnnotationLevel: github.String("failure"),
+			Title:           github.String("rule_1"),
+			Message:         github.String("violation_1"),
+		},
+		{
+			Path:            github.String("file-1"),
+			StartLine:       github.Int(4),
+			EndLine:         github.Int(6),
+			AnnotationLevel: github.String("warning"),
+			Title:           github.String("rule_2"),
+			Message:         github.String("violation_2"),
+		},
+		{
+			Path:            github.String("file-3"),
+			StartLine:       github.Int(1),
+			EndLine:         github.Int(1),
+			AnnotationLevel: github.String("notice"),
+			Title:           github.String("rule_3 (new)"),
+			Message:         github.String("violation_3"),
+		},
+	}, Annotations(results))
+}
+
+func decisionWith(allow bool, severities ...reviewer.Severity) *reviewer.Decision {
+	violations := make([]reviewer.Violation, 0, len(severities))
+	for _, severity := range severities {
+		violations = append(violations, reviewer.Violation{Severity: severity})
+	}
+
+	return &reviewer.Decision{Allow: allow, Violations: violations}
+}
@@ -0,0 +1,90 @@
+package presentation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+)
+
+// Explanation renders the results of reviewing a single file in details, with the package, resource, path and lines of
+// every violation, along with its metadata and suggested change.
+func Explanation(file string, results []review.Result) string {
+	rows := []string{fmt.Sprintf("Explanation of `%s`:", file)}
+	if len(results) == 0 {
+		return rows[0] + " no policy reviews this file.\n"
+	}
+
+	for idx := range results {
+		rows = append(rows, "", fmt.Sprintf("#### %s", resultName(&results[idx])), "")
+		if results[idx].Error != nil {
+			rows = append(rows, fmt.Sprintf("Error: %s", results[idx].Error))
+			continue
+		}
+
+		decision := results[idx].Decision
+		rows = append(rows, fmt.Sprintf("Outcome: %s", outcome(decision.Allow, decision.HighestSeverity())))
+		for vIdx := range decision.Violations {
+			rows = append(rows, explainViolation(&decision.Violations[vIdx], decision.Documents > 1)...)
+		}
+	}
+
+	return strings.Join(rows, "\n") + "\n"
+}
+
+// explainViolation renders a violation as a list row, followed by a nested list of its details.
+func explainViolation(violation *reviewer.Violation, multiDocument bool) []string {
+	rows := []string{"", "* " + InlineComment(violation)}
+	detail := func(name, value string) {
+		if value != "" {
+			rows = append(rows, fmt.Sprintf("  * %s: %s", name, value))
+		}
+	}
+
+	detail("Package", code(violation.Package))
+	detail("Change", string(violation.Change))
+	detail("Resource", code(violation.ResourceID))
+	detail("Path", code(string(violation.Path)))
+	if multiDocument {
+		detail("Document", fmt.Sprint(violation.Document))
+	}
+
+	if violation.Location.StartLine > 0 {
+		detail("Lines", fmt.Sprintf("%d-%d", violation.Location.StartLine, violation.Location.EndLine))
+	}
+
+	keys := make([]string, 0, len(violation.Metadata))
+	for key := range violation.Metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		detail(key, code(fmt.Sprint(violation.Metadata[key])))
+	}
+
+	// Suggested changes can only be applied from review comments, so the suggestion is rendered as a plain block.
+	if violation.Suggestion != nil {
+		block := fmt.Sprintf("```\n%s\n```", violation.Suggestion.Content)
+		rows = append(rows, "", fmt.Sprintf("  Suggested change of lines %d-%d:", violation.Suggestion.StartLine,
+			violation.Suggestion.EndLine), "", indent(block, "  "))
+	}
+
+	return rows
+}
+
+// code renders a non-empty value as inline code.
+func code(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("`%s`", value)
+}
+
+// indent prefixes every line of the text with the given prefix.
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
@@ -0,0 +1,80 @@
+package presentation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplanation(t *testing.T) {
+	cases := map[string]struct {
+		results  []review.Result
+		expected string
+	}{
+		"explain violations": {
+			results: []review.Result{
+				{
+					File:  "k8s/app.yaml",
+					Route: "kubernetes",
+					Decision: &reviewer.Decision{
+						Violations: []reviewer.Violation{
+							{
+								RuleID:     "rule_1",
+								Severity:   reviewer.SeverityError,
+								Message:    "violation_1",
+								Package:    "reviewer.k8s",
+								ResourceID: "Deployment/app",
+								Path:       "/spec/privileged",
+								Metadata:   map[string]any{"url": "https://example.com", "cis": "5.2.1"},
+								Document:   1,
+								Location:   reviewer.Location{StartLine: 3, EndLine: 4},
+								Change:     reviewer.ViolationNew,
+								Suggestion: &reviewer.Suggestion{StartLine: 3, EndLine: 3, Content: "privileged: false"},
+							},
+						},
+						Documents: 2,
+					},
+				},
+				{File: "k8s/app.yaml", Route: "default", Error: errors.New("error_1")},
+			},
+			expected: "Explanation of `k8s/app.yaml`:" + `
+
+#### k8s/app.yaml [kubernetes]
+
+Outcome: failed
+
+* **[error] rule_1**: violation_1
+  * Package: ` + "`reviewer.k8s`" + `
+  * Change: new
+  * Resource: ` + "`Deployment/app`" + `
+  * Path: ` + "`/spec/privileged`" + `
+  * Document: 1
+  * Lines: 3-4
+  * cis: ` + "`5.2.1`" + `
+  * url: ` + "`https://example.com`" + `
+
+  Suggested change of lines 3-3:
+
+  ` + "```" + `
+  privileged: false
+  ` + "```" + `
+
+#### k8s/app.yaml [default]
+
+Error: error_1
+`,
+		},
+		"explain file without review": {
+			expected: "Explanation of `k8s/app.yaml`: no policy reviews this file.\n",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Explanation("k8s/app.yaml", tc.results))
+		})
+	}
+}
@@ -3,12 +3,23 @@ package presentation
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"text/template"
+	"unicode/utf8"
 
 	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+)
+
+const (
+	outcomeFailed             = "failed"
+	outcomePassedWithWarnings = "passed with warnings"
+	outcomePassed             = "passed"
+	truncatedNote             = "\n\n_The output is truncated as it exceeds the size accepted by GitHub._\n"
 )
 
 type markdownData struct {
+	Outcome string
 	Reviews []string
 	Errors  []string
 }
@@ -23,14 +34,15 @@ func Markdown(results []review.Result) string {
 
 	for _, result := range results {
 		if result.Error != nil {
-			errors = append(errors, markdownListRow(result.File, result.Error.Error()))
+			errors = append(errors, markdownListRow(resultName(&result), result.Error.Error()))
 			continue
 		}
 
-		reviews = append(reviews, markdownListRow(result.File, string(result.Output)))
+		reviews = append(reviews, markdownDecision(resultName(&result), result.Decision))
 	}
 
-	outputTmpl := `{{if .Reviews -}}
+	outputTmpl := `Outcome: {{.Outcome}}
+{{if .Reviews}}
 Reviews:
 {{range .Reviews}}{{.}}
 {{end}}{{end}}{{if .Errors}}
@@ -41,11 +53,117 @@ Errors:
 	tmpl := template.Must(template.New("outputTmpl").Parse(outputTmpl))
 
 	var output bytes.Buffer
-	_ = tmpl.Execute(&output, markdownData{Reviews: reviews, Errors: errors})
+	_ = tmpl.Execute(&output, markdownData{
+		Outcome: Outcome(results),
+		Reviews: reviews,
+		Errors:  errors,
+	})
 
 	return output.String()
 }
 
+// Outcome describes the overall outcome of the given results, e.g. passed with warnings.
+func Outcome(results []review.Result) string {
+	return outcome(review.Allowed(results), review.HighestSeverity(results))
+}
+
+// markdownDecision renders the outcome of a file review followed by a nested list of its violations, and of the
+// violations of the base content which were fixed.
+func markdownDecision(file string, decision *reviewer.Decision) string {
+	rows := []string{markdownListRow(file, outcome(decision.Allow, decision.HighestSeverity()))}
+	for idx := range decision.Violations {
+		rows = append(rows, "  "+markdownViolation(&decision.Violations[idx], decision.Documents > 1))
+	}
+
+	for idx := range decision.Fixed {
+		// The location of a fixed violation is in the base content, so it is left out.
+		fixed := decision.Fixed[idx]
+		fixed.Location = reviewer.Location{}
+		rows = append(rows, "  "+markdownViolation(&fixed, false))
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+// outcome describes the review outcome, which is decided by the allow flag and the highest severity found.
+// Error level violations of allowed files, e.g. the ones not introduced by the change, are reported as warnings.
+func outcome(allow bool, severity reviewer.Severity) string {
+	switch {
+	case !allow:
+		return outcomeFailed
+	case severity == reviewer.SeverityError || severity == reviewer.SeverityWarning:
+		return outcomePassedWithWarnings
+	default:
+		return outcomePassed
+	}
+}
+
+// markdownViolation renders a violation as a list row.
+func markdownViolation(violation *reviewer.Violation, multiDocument bool) string {
+	return "* " + violationText(violation, multiDocument)
+}
+
+// violationText renders a violation, followed by its change against the base content, the offending resource,
+// the index of the offending document for multi-document files, and the line of the offending node.
+func violationText(violation *reviewer.Violation, multiDocument bool) string {
+	text := fmt.Sprintf("[%s] %s: %s", violation.Severity, violation.RuleID, violation.Message)
+
+	details := make([]string, 0)
+	if violation.Change != "" {
+		details = append(details, string(violation.Change))
+	}
+
+	if violation.ResourceID != "" {
+		details = append(details, violation.ResourceID)
+	}
+
+	if multiDocument {
+		details = append(details, fmt.Sprintf("document %d", violation.Document))
+	}
+
+	if violation.Location.StartLine > 0 {
+		details = append(details, fmt.Sprintf("line %d", violation.Location.StartLine))
+	}
+
+	if len(details) > 0 {
+		text = fmt.Sprintf("%s (%s)", text, strings.Join(details, ", "))
+	}
+
+	return text
+}
+
+// resultName returns the file name of the result, followed by the route it was reviewed with if any.
+// The reviews of the changeset and of the pull request are named after their route.
+func resultName(result *review.Result) string {
+	if !result.IsFile() {
+		return result.Route
+	}
+
+	if result.Route == "" {
+		return result.File
+	}
+
+	return fmt.Sprintf("%s [%s]", result.File, result.Route)
+}
+
 func markdownListRow(file, comment string) string {
 	return fmt.Sprintf("* %s: %s", file, comment)
 }
+
+// Truncate cuts the text to at most limit bytes, at the end of a line when possible, and notes that it is truncated.
+func Truncate(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+
+	cut := text[:max(limit-len(truncatedNote), 0)]
+	if idx := strings.LastIndex(cut, "\n"); idx > 0 {
+		cut = cut[:idx]
+	}
+
+	for len(cut) > 0 && !utf8.ValidString(cut) {
+		cut = cut[:len(cut)-1]
+	}
+
+	return cut + truncatedNote
+}
@@ -2,9 +2,11 @@ package presentation
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -20,24 +22,104 @@ func TestMarkdown(t *testing.T) {
 		"display results and errors": {
 			results: []review.Result{
 				{
-					File:   "file-1",
-					Output: []byte("outcome_1"),
+					File:     "file-1",
+					Decision: &reviewer.Decision{Allow: true},
 				},
 				{
-					File:   "file-2",
-					Output: []byte("outcome_2"),
+					File:  "file-2",
+					Route: "cfn",
+					Decision: &reviewer.Decision{
+						Allow: false,
+						Violations: []reviewer.Violation{
+							{
+								RuleID:     "rule_1",
+								Severity:   reviewer.SeverityError,
+								Message:    "violation_1",
+								ResourceID: "resource_1",
+								Location:   reviewer.Location{StartLine: 2, StartColumn: 3, EndLine: 4, EndColumn: 5},
+							},
+							{
+								RuleID:   "rule_2",
+								Severity: reviewer.SeverityWarning,
+								Message:  "violation_2",
+								Document: 1,
+							},
+						},
+						Documents: 2,
+					},
 				},
 				{
 					File:  "file-3",
 					Error: errors.New("error_1"),
 				},
 			},
-			expected: `Reviews:
-* file-1: outcome_1
-* file-2: outcome_2
+			expected: `Outcome: failed
+
+Reviews:
+* file-1: passed
+* file-2 [cfn]: failed
+  * [error] rule_1: violation_1 (resource_1, document 0, line 2)
+  * [warning] rule_2: violation_2 (document 1)
 
 Errors:
 * file-3: error_1
+`,
+		},
+		"display violations against base content": {
+			results: []review.Result{
+				{
+					File: "file-1",
+					Decision: &reviewer.Decision{
+						Allow: true,
+						Violations: []reviewer.Violation{
+							{
+								RuleID:   "rule_1",
+								Severity: reviewer.SeverityError,
+								Message:  "violation_1",
+								Location: reviewer.Location{StartLine: 2},
+								Change:   reviewer.ViolationUnchanged,
+							},
+						},
+						Fixed: []reviewer.Violation{
+							{
+								RuleID:   "rule_2",
+								Severity: reviewer.SeverityWarning,
+								Message:  "violation_2",
+								Location: reviewer.Location{StartLine: 8},
+								Change:   reviewer.ViolationFixed,
+							},
+						},
+						Documents: 1,
+					},
+				},
+			},
+			expected: `Outcome: passed with warnings
+
+Reviews:
+* file-1: passed with warnings
+  * [error] rule_1: violation_1 (unchanged, line 2)
+  * [warning] rule_2: violation_2 (fixed)
+`,
+		},
+		"display changeset result": {
+			results: []review.Result{
+				{File: "file-1", Decision: &reviewer.Decision{Allow: true}},
+				{
+					Route: review.ChangesetRoute,
+					Decision: &reviewer.Decision{
+						Violations: []reviewer.Violation{
+							{RuleID: "rule_1", Severity: reviewer.SeverityError, Message: "violation_1"},
+						},
+						Documents: 1,
+					},
+				},
+			},
+			expected: `Outcome: failed
+
+Reviews:
+* file-1: passed
+* changeset: failed
+  * [error] rule_1: violation_1
 `,
 		},
 	}
@@ -50,3 +132,35 @@ Errors:
 		})
 	}
 }
+
+func TestTruncate(t *testing.T) {
+	cases := map[string]struct {
+		text     string
+		limit    int
+		expected string
+	}{
+		"keep text within limit": {
+			text:     "* file-1: passed\n",
+			limit:    100,
+			expected: "* file-1: passed\n",
+		},
+		"cut text at end of line": {
+			text:     "* file-1: passed\n" + strings.Repeat("* file-2: failed\n", 10),
+			limit:    len(truncatedNote) + 20,
+			expected: "* file-1: passed" + truncatedNote,
+		},
+		"cut text without line at rune boundary": {
+			text:     strings.Repeat("é", 100),
+			limit:    len(truncatedNote) + 5,
+			expected: "éé" + truncatedNote,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual := Truncate(tc.text, tc.limit)
+			assert.Equal(t, tc.expected, actual)
+			assert.LessOrEqual(t, len(actual), tc.limit)
+		})
+	}
+}
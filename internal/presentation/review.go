@@ -0,0 +1,47 @@
+package presentation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+)
+
+// FileViolation is a violation along with the file it was found in.
+type FileViolation struct {
+	File      string              `json:"file"`
+	Violation *reviewer.Violation `json:"violation"`
+}
+
+// InlineComment renders a violation as a review comment attached to the offending line.
+func InlineComment(violation *reviewer.Violation) string {
+	return fmt.Sprintf("**[%s] %s**: %s", violation.Severity, violation.RuleID, violation.Message)
+}
+
+// SuggestionBlock renders a suggestion as a suggested change, which replaces the lines the review comment spans.
+func SuggestionBlock(suggestion *reviewer.Suggestion) string {
+	if suggestion.Content == "" {
+		return "```suggestion\n```"
+	}
+
+	return fmt.Sprintf("```suggestion\n%s\n```", suggestion.Content)
+}
+
+// ReviewBody renders the violations which could not be attached to a changed line as the body of a review.
+func ReviewBody(violations []FileViolation) string {
+	if len(violations) == 0 {
+		return ""
+	}
+
+	rows := []string{"Violations outside of the changed lines:", ""}
+	for idx := range violations {
+		rows = append(rows, ViolationRow(&violations[idx]))
+	}
+
+	return strings.Join(rows, "\n") + "\n"
+}
+
+// ViolationRow renders a violation as a list row prefixed with its file.
+func ViolationRow(violation *FileViolation) string {
+	return markdownListRow(violation.File, violationText(violation.Violation, false))
+}
@@ -0,0 +1,59 @@
+package presentation
+
+import (
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInlineComment(t *testing.T) {
+	assert.Equal(t, "**[warning] rule_1**: violation_1", InlineComment(&reviewer.Violation{
+		RuleID:   "rule_1",
+		Severity: reviewer.SeverityWarning,
+		Message:  "violation_1",
+	}))
+}
+
+func TestSuggestionBlock(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("```suggestion\n  a: 1\n  b: 2\n```", SuggestionBlock(&reviewer.Suggestion{Content: "  a: 1\n  b: 2"}))
+	a.Equal("```suggestion\n```", SuggestionBlock(&reviewer.Suggestion{Content: ""}))
+}
+
+func TestReviewBody(t *testing.T) {
+	cases := map[string]struct {
+		violations []FileViolation
+		expected   string
+	}{
+		"no violations": {
+			violations: make([]FileViolation, 0),
+			expected:   "",
+		},
+		"list violations with their files": {
+			violations: []FileViolation{
+				{
+					File: "file-1",
+					Violation: &reviewer.Violation{
+						RuleID:     "rule_1",
+						Severity:   reviewer.SeverityError,
+						Message:    "violation_1",
+						ResourceID: "resource_1",
+						Location:   reviewer.Location{StartLine: 2},
+					},
+				},
+				{
+					File:      "file-2",
+					Violation: &reviewer.Violation{RuleID: "rule_2", Severity: reviewer.SeverityInfo, Message: "violation_2"},
+				},
+			},
+			expected: "Violations outside of the changed lines:\n\n* file-1: [error] rule_1: violation_1 (resource_1, line 2)\n* file-2: [info] rule_2: violation_2\n", // nolint: lll
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ReviewBody(tc.violations))
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package presentation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+)
+
+// OpenViolations returns the violations of the reviewed files, sorted by file, line and rule. The details which are
+// only relevant to a change, e.g. the fixes and suggestions, are left out.
+func OpenViolations(results []review.Result) []FileViolation {
+	violations := make([]FileViolation, 0)
+	for idx := range results {
+		if !results[idx].IsFile() || results[idx].Decision == nil {
+			continue
+		}
+
+		for _, violation := range results[idx].Decision.Violations {
+			violations = append(violations, FileViolation{
+				File: results[idx].File,
+				Violation: &reviewer.Violation{
+					RuleID:     violation.RuleID,
+					Severity:   violation.Severity,
+					Message:    violation.Message,
+					ResourceID: violation.ResourceID,
+					Package:    violation.Package,
+					Location:   reviewer.Location{StartLine: violation.Location.StartLine},
+				},
+			})
+		}
+	}
+
+	SortViolations(violations)
+	return violations
+}
+
+// SortViolations sorts the violations by file, line, rule and message.
+func SortViolations(violations []FileViolation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		switch {
+		case a.File != b.File:
+			return a.File < b.File
+		case a.Violation.Location.StartLine != b.Violation.Location.StartLine:
+			return a.Violation.Location.StartLine < b.Violation.Location.StartLine
+		case a.Violation.RuleID != b.Violation.RuleID:
+			return a.Violation.RuleID < b.Violation.RuleID
+		default:
+			return a.Violation.Message < b.Violation.Message
+		}
+	})
+}
+
+// TrackingIssue renders the open violations of a branch as the content of its tracking issue.
+func TrackingIssue(branch string, violations []FileViolation) string {
+	if len(violations) == 0 {
+		return fmt.Sprintf("All the violations of `%s` are fixed.\n", branch)
+	}
+
+	rows := []string{fmt.Sprintf("Open violations of `%s`:", branch), ""}
+	for idx := range violations {
+		rows = append(rows, ViolationRow(&violations[idx]))
+	}
+
+	return strings.Join(rows, "\n") + "\n"
+}
@@ -0,0 +1,90 @@
+package presentation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenViolations(t *testing.T) {
+	results := []review.Result{
+		{
+			File: "file-2",
+			Decision: &reviewer.Decision{Violations: []reviewer.Violation{{
+				RuleID:     "rule_2",
+				Severity:   reviewer.SeverityError,
+				Message:    "violation_2",
+				Package:    "reviewer.cfn",
+				Location:   reviewer.Location{StartLine: 3, EndLine: 4},
+				Change:     reviewer.ViolationNew,
+				Suggestion: &reviewer.Suggestion{Content: "a: 1"},
+			}}},
+		},
+		{
+			File: "file-1",
+			Decision: &reviewer.Decision{Violations: []reviewer.Violation{
+				{RuleID: "rule_3", Severity: reviewer.SeverityInfo, Message: "violation_3", Location: line(5)},
+				{RuleID: "rule_1", Severity: reviewer.SeverityWarning, Message: "violation_1", Location: line(5)},
+			}},
+		},
+		{File: "file-3", Error: errors.New("invalid file")},
+		{Route: review.ChangesetRoute, Decision: &reviewer.Decision{Violations: []reviewer.Violation{{RuleID: "rule_4"}}}},
+	}
+
+	assert.Equal(t, []FileViolation{
+		{
+			File: "file-1",
+			Violation: &reviewer.Violation{
+				RuleID:   "rule_1",
+				Severity: reviewer.SeverityWarning,
+				Message:  "violation_1",
+				Location: reviewer.Location{StartLine: 5},
+			},
+		},
+		{
+			File: "file-1",
+			Violation: &reviewer.Violation{
+				RuleID:   "rule_3",
+				Severity: reviewer.SeverityInfo,
+				Message:  "violation_3",
+				Location: reviewer.Location{StartLine: 5},
+			},
+		},
+		{
+			File: "file-2",
+			Violation: &reviewer.Violation{
+				RuleID:   "rule_2",
+				Severity: reviewer.SeverityError,
+				Message:  "violation_2",
+				Package:  "reviewer.cfn",
+				Location: reviewer.Location{StartLine: 3},
+			},
+		},
+	}, OpenViolations(results))
+}
+
+func TestTrackingIssue(t *testing.T) {
+	a := assert.New(t)
+
+	a.Equal("All the violations of `main` are fixed.\n", TrackingIssue("main", nil))
+	a.Equal(
+		"Open violations of `main`:\n\n* file-1: [error] rule_1: violation_1 (resource_1, line 2)\n",
+		TrackingIssue("main", []FileViolation{{
+			File: "file-1",
+			Violation: &reviewer.Violation{
+				RuleID:     "rule_1",
+				Severity:   reviewer.SeverityError,
+				Message:    "violation_1",
+				ResourceID: "resource_1",
+				Location:   reviewer.Location{StartLine: 2},
+			},
+		}}),
+	)
+}
+
+func line(start int) reviewer.Location {
+	return reviewer.Location{StartLine: start}
+}
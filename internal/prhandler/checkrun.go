@@ -0,0 +1,106 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/google/go-github/v58/github"
+)
+
+const (
+	checkRunName             = "OPA Review"
+	checkRunStatusInProgress = "in_progress"
+	checkRunStatusCompleted  = "completed"
+
+	// maxAnnotationsPerRequest is the number of annotations GitHub accepts in a single check run request.
+	maxAnnotationsPerRequest = 50
+	// maxCheckRunSummary is the length of the check run summary accepted by GitHub.
+	maxCheckRunSummary = 65535
+)
+
+// checkRunOutput is the outcome a check run is completed with.
+type checkRunOutput struct {
+	conclusion  string
+	title       string
+	summary     string
+	annotations []*github.CheckRunAnnotation
+}
+
+// newCheckRunOutput builds the check run output of the given review results.
+func newCheckRunOutput(results []review.Result) *checkRunOutput {
+	return &checkRunOutput{
+		conclusion:  presentation.Conclusion(results),
+		title:       presentation.Title(results),
+		summary:     presentation.Markdown(results),
+		annotations: presentation.Annotations(results),
+	}
+}
+
+// failCheckRun completes a check run as failed with the error which interrupted the review, and returns the error.
+func failCheckRun(ctx context.Context, client *github.Client, pr *pullRequest, id int64, reviewErr error) error {
+	checkRunErr := completeCheckRun(ctx, client, pr, id, &checkRunOutput{
+		conclusion: presentation.ConclusionFailure,
+		title:      "review failed",
+		summary:    reviewErr.Error(),
+	})
+
+	return errors.Join(reviewErr, checkRunErr)
+}
+
+// createCheckRun creates an in progress check run on the head commit of the pull request and returns its ID.
+func createCheckRun(ctx context.Context, client *github.Client, pr *pullRequest) (int64, error) {
+	checkRun, _, err := client.Checks.CreateCheckRun(ctx, pr.getOwner(), pr.getRepoName(), github.CreateCheckRunOptions{
+		Name:    checkRunName,
+		HeadSHA: pr.sha,
+		Status:  github.String(checkRunStatusInProgress),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return checkRun.GetID(), nil
+}
+
+// completeCheckRun completes a check run with the given output. Annotations are sent in batches of
+// maxAnnotationsPerRequest, the check run is only completed along with the last batch. The summary is truncated to
+// the length accepted by GitHub.
+func completeCheckRun(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	id int64,
+	output *checkRunOutput,
+) error {
+	annotations := output.annotations
+	summary := presentation.Truncate(output.summary, maxCheckRunSummary)
+	for {
+		batch := annotations[:min(len(annotations), maxAnnotationsPerRequest)]
+		annotations = annotations[len(batch):]
+
+		opts := github.UpdateCheckRunOptions{
+			Name: checkRunName,
+			Output: &github.CheckRunOutput{
+				Title:       github.String(output.title),
+				Summary:     github.String(summary),
+				Annotations: batch,
+			},
+		}
+
+		if len(annotations) == 0 {
+			opts.Status = github.String(checkRunStatusCompleted)
+			opts.Conclusion = github.String(output.conclusion)
+			opts.CompletedAt = &github.Timestamp{Time: time.Now()}
+		}
+
+		if _, _, err := client.Checks.UpdateCheckRun(ctx, pr.getOwner(), pr.getRepoName(), id, opts); err != nil {
+			return err
+		}
+
+		if len(annotations) == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteCheckRun(t *testing.T) {
+	cases := map[string]struct {
+		annotations         int
+		summary             string
+		expectedAnnotations []int
+	}{
+		"complete check run without annotations": {
+			annotations:         0,
+			expectedAnnotations: []int{0},
+		},
+		"complete check run with a single batch of annotations": {
+			annotations:         maxAnnotationsPerRequest,
+			expectedAnnotations: []int{maxAnnotationsPerRequest},
+		},
+		"complete check run with truncated summary": {
+			summary:             strings.Repeat("* file.yaml: failed\n", maxCheckRunSummary),
+			expectedAnnotations: []int{0},
+		},
+		"complete check run with multiple batches of annotations": {
+			annotations:         maxAnnotationsPerRequest*2 + 1,
+			expectedAnnotations: []int{maxAnnotationsPerRequest, maxAnnotationsPerRequest, 1},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			requests := make([]*github.UpdateCheckRunOptions, 0)
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.PatchReposCheckRunsByOwnerByRepoByCheckRunId,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						opts := new(github.UpdateCheckRunOptions)
+						_ = json.NewDecoder(req.Body).Decode(opts)
+						requests = append(requests, opts)
+						_, _ = w.Write(mock.MustMarshal(github.CheckRun{ID: github.Int64(1)}))
+					}),
+				),
+			))
+
+			annotations := make([]*github.CheckRunAnnotation, 0, tc.annotations)
+			for idx := 0; idx < tc.annotations; idx++ {
+				annotations = append(annotations, &github.CheckRunAnnotation{Path: github.String("file.yaml")})
+			}
+
+			err := completeCheckRun(context.TODO(), client, getPullRequest(), 1, &checkRunOutput{
+				conclusion:  "failure",
+				title:       "title",
+				summary:     tc.summary,
+				annotations: annotations,
+			})
+
+			a.Nil(err)
+			a.Len(requests, len(tc.expectedAnnotations))
+			for idx, expected := range tc.expectedAnnotations {
+				last := idx == len(tc.expectedAnnotations)-1
+				a.Len(requests[idx].Output.Annotations, expected)
+				a.Equal("title", requests[idx].Output.GetTitle())
+				a.LessOrEqual(len(requests[idx].Output.GetSummary()), maxCheckRunSummary)
+				a.Equal(last, requests[idx].GetStatus() == checkRunStatusCompleted)
+				a.Equal(last, requests[idx].GetConclusion() == "failure")
+			}
+		})
+	}
+}
+
+func getPullRequest() *pullRequest {
+	return &pullRequest{
+		num: 2,
+		repo: &github.Repository{
+			Name:  github.String("repo"),
+			Owner: &github.User{Login: github.String("owner")},
+		},
+		sha: "12345",
+	}
+}
@@ -0,0 +1,192 @@
+package prhandler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/reader"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+)
+
+const (
+	issueCommentEvent     = "issue_comment"
+	commentCreatedAction  = "created"
+	commandPrefix         = "/opa-review"
+	commandRerun          = "rerun"
+	commandExplain        = "explain"
+	commandHelp           = "help"
+	permissionAdmin       = "admin"
+	permissionWrite       = "write"
+	commandHelpText       = "Usage:\n\n* `/opa-review rerun`: reviews the pull request again.\n* `/opa-review explain <file>`: explains the violations of a changed file.\n* `/opa-review help`: shows this message.\n" // nolint: lll
+	commandPermissionText = "only the collaborators with write permission may run `/opa-review` commands."
+)
+
+// command is a slash command posted as a comment of a pull request, e.g. /opa-review explain stack/app.yaml.
+type command struct {
+	name string
+	args []string
+}
+
+// parseCommand returns the command of the first line of the comment body starting with the command prefix, if any.
+// A bare command prefix is the help command.
+func parseCommand(body string) (*command, bool) {
+	for _, line := range strings.Split(body, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != commandPrefix {
+			continue
+		}
+
+		if len(fields) == 1 {
+			return &command{name: commandHelp}, true
+		}
+
+		return &command{name: fields[1], args: fields[2:]}, true
+	}
+
+	return nil, false
+}
+
+// handleComment runs the command of a comment newly posted on a pull request, once the commenter is found to have
+// write permission on the repository. Other comments are ignored.
+func (h *handler) handleComment(ctx context.Context, payload []byte) error {
+	event, eventErr := parseIssueCommentEvent(payload)
+	if eventErr != nil {
+		return eventErr
+	}
+
+	cmd, ok := parseCommand(event.GetComment().GetBody())
+	if event.GetAction() != commentCreatedAction || !event.GetIssue().IsPullRequest() || !ok {
+		return nil
+	}
+
+	installationID := event.GetInstallation().GetID()
+	repo := event.GetRepo()
+	ctx, logger := githubapp.PreparePRContext(ctx, installationID, repo, event.GetIssue().GetNumber())
+
+	client, clientErr := h.clientCreator.NewInstallationClient(installationID)
+	if clientErr != nil {
+		return clientErr
+	}
+
+	details, _, prErr := client.PullRequests.Get(
+		ctx,
+		repo.GetOwner().GetLogin(),
+		repo.GetName(),
+		event.GetIssue().GetNumber(),
+	)
+	if prErr != nil {
+		return prErr
+	}
+
+	pr := newPullRequest(details, repo, installationID, "")
+	login := event.GetComment().GetUser().GetLogin()
+
+	permission, _, permissionErr := client.Repositories.GetPermissionLevel(ctx, pr.getOwner(), pr.getRepoName(), login)
+	if permissionErr != nil {
+		return permissionErr
+	}
+
+	if !canRunCommands(permission.GetPermission()) {
+		logger.Info().Msgf("ignoring command %s of %s without write permission", cmd.name, login)
+		return postComment(ctx, client, pr, fmt.Sprintf("@%s %s", login, commandPermissionText))
+	}
+
+	logger.Info().Msgf("running command %s of %s on %s", cmd.name, login, pr.getPullRequestString())
+	return h.runCommand(ctx, client, pr, cmd)
+}
+
+// runCommand runs the command on the pull request, with the repository configuration of its default branch.
+func (h *handler) runCommand(ctx context.Context, client *github.Client, pr *pullRequest, cmd *command) error {
+	switch {
+	case cmd.name == commandHelp:
+		return postComment(ctx, client, pr, commandHelpText)
+	case cmd.name != commandRerun && cmd.name != commandExplain:
+		return postComment(ctx, client, pr, fmt.Sprintf("Unknown command `%s`.\n\n%s", cmd.name, commandHelpText))
+	case cmd.name == commandExplain && len(cmd.args) != 1:
+		return postComment(ctx, client, pr, fmt.Sprintf("Missing the file to explain.\n\n%s", commandHelpText))
+	}
+
+	return h.withConfig(ctx, client, pr, func(cfg *repoconfig.Config) error {
+		if cmd.name == commandRerun {
+			return h.review(ctx, client, pr, cfg)
+		}
+
+		return h.explain(ctx, client, pr, cfg, cmd.args[0])
+	})
+}
+
+// explain reviews a single changed file of the pull request, and replies with the details of its violations.
+func (h *handler) explain(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	cfg *repoconfig.Config,
+	file string,
+) error {
+	if err := pr.resolveMergeBase(ctx, client); err != nil {
+		return err
+	}
+
+	files, filesErr := getChangedFiles(ctx, client, pr.getOwner(), pr.getRepoName(), pr.num)
+	if filesErr != nil {
+		return filesErr
+	}
+
+	var target *review.ChangedFile
+	matched := getMatchingFiles(toChangedFiles(files), h.matcher, cfg)
+	for idx := range matched {
+		if matched[idx].Name == file {
+			target = &matched[idx]
+			break
+		}
+	}
+
+	if target == nil {
+		return postComment(ctx, client, pr, fmt.Sprintf("`%s` is not a reviewed file of this pull request.\n", file))
+	}
+
+	results, reviewErr := h.reviewSvc.Review(
+		ctx,
+		reader.ReadGitHubFile(client, pr.getOwner(), pr.getRepoName(), pr.sha),
+		[]review.ChangedFile{*target},
+		review.WithBase(reader.ReadGitHubFile(client, pr.getOwner(), pr.getRepoName(), pr.baseSHA)),
+		review.WithEnvelope(pr.envelope()),
+	)
+	if reviewErr != nil {
+		return reviewErr
+	}
+
+	fileResults := make([]review.Result, 0, len(results))
+	for _, result := range cfg.Apply(results) {
+		if result.IsFile() {
+			fileResults = append(fileResults, result)
+		}
+	}
+
+	return postComment(ctx, client, pr, presentation.Explanation(file, fileResults))
+}
+
+// parseIssueCommentEvent parses the payload of an issue comment event.
+func parseIssueCommentEvent(payload []byte) (*github.IssueCommentEvent, error) {
+	event, err := github.ParseWebHook(issueCommentEvent, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	commentEvent, ok := event.(*github.IssueCommentEvent)
+	if !ok {
+		return nil, fmt.Errorf("unexpected event payload type %s found", issueCommentEvent)
+	}
+
+	return commentEvent, nil
+}
+
+// canRunCommands reports whether a collaborator with the given permission level may run commands.
+func canRunCommands(permission string) bool {
+	return permission == permissionAdmin || permission == permissionWrite
+}
@@ -0,0 +1,208 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommand(t *testing.T) {
+	cases := map[string]struct {
+		body     string
+		expected *command
+	}{
+		"parse command with args": {
+			body:     "/opa-review explain stack/app.yaml",
+			expected: &command{name: "explain", args: []string{"stack/app.yaml"}},
+		},
+		"parse command of first matching line": {
+			body:     "Thanks!\n  /opa-review rerun\n/opa-review help",
+			expected: &command{name: "rerun", args: []string{}},
+		},
+		"parse bare prefix as help": {
+			body:     "/opa-review",
+			expected: &command{name: "help"},
+		},
+		"ignore comment without command": {
+			body: "please /opa-review rerun",
+		},
+		"ignore prefix of other command": {
+			body: "/opa-reviewer rerun",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			cmd, ok := parseCommand(tc.body)
+			assert.Equal(t, tc.expected != nil, ok)
+			assert.Equal(t, tc.expected, cmd)
+		})
+	}
+}
+
+func TestHandler_Handle_Comment(t *testing.T) {
+	cases := map[string]struct {
+		payload            []byte
+		permission         string
+		expectedComment    string
+		expectedConclusion string
+	}{
+		"reply help": {
+			payload:         getIssueCommentPayload("created", "/opa-review help", true),
+			permission:      "write",
+			expectedComment: commandHelpText,
+		},
+		"reply unknown command": {
+			payload:         getIssueCommentPayload("created", "/opa-review approve", true),
+			permission:      "admin",
+			expectedComment: "Unknown command `approve`.\n\n" + commandHelpText,
+		},
+		"reply missing file to explain": {
+			payload:         getIssueCommentPayload("created", "/opa-review explain", true),
+			permission:      "write",
+			expectedComment: "Missing the file to explain.\n\n" + commandHelpText,
+		},
+		"rerun review": {
+			payload:            getIssueCommentPayload("created", "/opa-review rerun", true),
+			permission:         "write",
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: passed -->\nOutcome: passed\n\nReviews:\n* stack/file_1.yaml: passed\n", // nolint: lll
+			expectedConclusion: "success",
+		},
+		"explain file": {
+			payload:         getIssueCommentPayload("created", "/opa-review explain stack/file_1.yaml", true),
+			permission:      "write",
+			expectedComment: "Explanation of `stack/file_1.yaml`:\n\n#### stack/file_1.yaml\n\nOutcome: passed\n",
+		},
+		"reply file not reviewed": {
+			payload:         getIssueCommentPayload("created", "/opa-review explain docs/README.md", true),
+			permission:      "write",
+			expectedComment: "`docs/README.md` is not a reviewed file of this pull request.\n",
+		},
+		"reply missing permission": {
+			payload:         getIssueCommentPayload("created", "/opa-review rerun", true),
+			permission:      "read",
+			expectedComment: "@commenter " + commandPermissionText,
+		},
+		"ignore edited comment": {
+			payload:    getIssueCommentPayload("edited", "/opa-review rerun", true),
+			permission: "write",
+		},
+		"ignore issue comment": {
+			payload:    getIssueCommentPayload("created", "/opa-review rerun", false),
+			permission: "write",
+		},
+		"ignore comment without command": {
+			payload:    getIssueCommentPayload("created", "LGTM", true),
+			permission: "write",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var comment string
+			var conclusion string
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(
+					mock.GetReposPullsByOwnerByRepoByPullNumber,
+					github.PullRequest{
+						Number: github.Int(2),
+						Head:   &github.PullRequestBranch{SHA: github.String("12345")},
+						Base:   &github.PullRequestBranch{SHA: github.String("67890")},
+					},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposCompareByOwnerByRepoByBasehead,
+					github.CommitsComparison{MergeBaseCommit: &github.RepositoryCommit{SHA: github.String("abcde")}},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposCollaboratorsPermissionByOwnerByRepoByUsername,
+					github.RepositoryPermissionLevel{Permission: github.String(tc.permission)},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposPullsFilesByOwnerByRepoByPullNumber,
+					toCommitFiles([]string{"stack/file_1.yaml", "docs/README.md"}, "modified"),
+				),
+				mock.WithRequestMatchHandler(
+					mock.GetReposContentsByOwnerByRepoByPath,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						mock.WriteError(w, http.StatusNotFound, "not found")
+					}),
+				),
+				mock.WithRequestMatch(mock.PostReposCheckRunsByOwnerByRepo, github.CheckRun{ID: github.Int64(1)}),
+				mock.WithRequestMatchHandler(
+					mock.PatchReposCheckRunsByOwnerByRepoByCheckRunId,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						opts := new(github.UpdateCheckRunOptions)
+						_ = json.NewDecoder(req.Body).Decode(opts)
+						conclusion = opts.GetConclusion()
+						_, _ = w.Write(mock.MustMarshal(github.CheckRun{ID: github.Int64(1)}))
+					}),
+				),
+				mock.WithRequestMatch(
+					mock.GetReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					make([]*github.IssueComment, 0),
+				),
+				mock.WithRequestMatch(mock.GetApp, github.App{Slug: github.String("opa-reviewer")}),
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+						comment = decodeComment(req).GetBody()
+					}),
+				),
+			))
+
+			h := New(
+				&mockClientCreator{client: client},
+				filematch.New([]string{"stack/**/*.yaml"}),
+				new(mockReviewSvc),
+			)
+
+			err := h.Handle(context.TODO(), issueCommentEvent, "", tc.payload)
+
+			a.Nil(err)
+			a.Equal(tc.expectedComment, comment)
+			a.Equal(tc.expectedConclusion, conclusion)
+		})
+	}
+}
+
+func getIssueCommentPayload(action, body string, onPullRequest bool) []byte {
+	issue := map[string]any{"number": 2}
+	if onPullRequest {
+		issue["pull_request"] = map[string]any{"url": "https://api.github.com/repos/owner/repo/pulls/2"}
+	}
+
+	payload := map[string]any{
+		"action": action,
+		"issue":  issue,
+		"comment": map[string]any{
+			"body": body,
+			"user": map[string]any{
+				"login": "commenter",
+			},
+		},
+		"repository": map[string]any{
+			"name":           "repo",
+			"full_name":      "owner/repo",
+			"default_branch": "main",
+			"owner": map[string]any{
+				"login": "owner",
+			},
+		},
+		"installation": map[string]any{
+			"id": 12345678,
+		},
+	}
+
+	bs, _ := json.Marshal(payload)
+	return bs
+}
@@ -0,0 +1,250 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/google/go-github/v58/github"
+)
+
+const (
+	commentMarker        = "<!-- opa-reviewer -->"
+	commentRunPrefix     = "<!-- opa-reviewer-run: "
+	commentRunSuffix     = " -->"
+	commentHistoryStart  = "<summary>Previous reviews</summary>"
+	commentHistoryEnd    = "</details>"
+	commentHistoryBullet = "* "
+	maxCommentHistory    = 5
+	maxCommentBody       = 65536
+	shortSHALength       = 7
+	graphQLURL           = "../graphql"
+	minimizeCommentQuery = `mutation($id: ID!) { minimizeComment(input: {subjectId: $id, classifier: OUTDATED}) { clientMutationId } }` // nolint: lll
+	minimizedQuery       = `query($ids: [ID!]!) { nodes(ids: $ids) { ... on IssueComment { id isMinimized } } }`
+	maxNodesPerQuery     = 100
+)
+
+// upsertComment posts the review content as the sticky comment of the pull request. The latest comment of the bot
+// carrying the hidden marker is edited in place, keeping the summaries of the previous runs collapsed at the bottom,
+// and a new comment is only posted when there is none. Other marked comments are superseded and minimized as outdated
+// when minimize is set, unless they already are.
+func upsertComment(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	login string,
+	summary string,
+	content string,
+	minimize bool,
+) error {
+	comments, listErr := listMarkedComments(ctx, client, pr, login)
+	if listErr != nil {
+		return listErr
+	}
+
+	if len(comments) == 0 {
+		_, _, err := client.Issues.CreateComment(ctx, pr.getOwner(), pr.getRepoName(), pr.num, &github.IssueComment{
+			Body: github.String(renderComment(summary, content, nil)),
+		})
+
+		return err
+	}
+
+	latest := comments[len(comments)-1]
+	_, _, editErr := client.Issues.EditComment(ctx, pr.getOwner(), pr.getRepoName(), latest.GetID(), &github.IssueComment{
+		Body: github.String(renderComment(summary, content, commentHistory(latest.GetBody()))),
+	})
+	if editErr != nil {
+		return editErr
+	}
+
+	if !minimize {
+		return nil
+	}
+
+	superseded := make([]string, 0, len(comments)-1)
+	for _, comment := range comments[:len(comments)-1] {
+		superseded = append(superseded, comment.GetNodeID())
+	}
+
+	unminimized, queryErr := listUnminimized(ctx, client, superseded)
+	if queryErr != nil {
+		return queryErr
+	}
+
+	for _, nodeID := range unminimized {
+		if err := minimizeComment(ctx, client, nodeID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postComment posts a new comment on the pull request, e.g. the reply to a command. The body is truncated to the
+// length accepted by GitHub.
+func postComment(ctx context.Context, client *github.Client, pr *pullRequest, body string) error {
+	_, _, err := client.Issues.CreateComment(ctx, pr.getOwner(), pr.getRepoName(), pr.num, &github.IssueComment{
+		Body: github.String(presentation.Truncate(body, maxCommentBody)),
+	})
+
+	return err
+}
+
+// listMarkedComments returns the comments of the pull request posted by the bot with the given login and carrying
+// the hidden marker, oldest first. The marker alone could be copied by anyone commenting on the pull request.
+func listMarkedComments(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	login string,
+) ([]*github.IssueComment, error) {
+	opt := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: numResultsPerPage},
+	}
+
+	marked := make([]*github.IssueComment, 0)
+	for {
+		comments, resp, err := client.Issues.ListComments(ctx, pr.getOwner(), pr.getRepoName(), pr.num, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, comment := range comments {
+			if isBotComment(comment.GetUser(), login) && strings.HasPrefix(comment.GetBody(), commentMarker) {
+				marked = append(marked, comment)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.Page = resp.NextPage
+	}
+
+	return marked, nil
+}
+
+// isBotComment reports whether a comment was posted by the bot with the given login.
+func isBotComment(user *github.User, login string) bool {
+	return user.GetType() == botUserType && user.GetLogin() == login
+}
+
+// renderComment renders the sticky comment body, the summary of the run is kept in a hidden line so that it can be
+// moved to the history by the next run. The content is truncated so that the body fits the length accepted by GitHub.
+func renderComment(summary, content string, history []string) string {
+	header := commentMarker + "\n" + commentRunPrefix + summary + commentRunSuffix + "\n"
+	if len(history) == 0 {
+		return header + presentation.Truncate(content, maxCommentBody-len(header))
+	}
+
+	var footer strings.Builder
+	footer.WriteString(fmt.Sprintf("\n\n<details>\n%s\n\n", commentHistoryStart))
+	for _, entry := range history {
+		footer.WriteString(commentHistoryBullet + entry + "\n")
+	}
+	footer.WriteString(commentHistoryEnd + "\n")
+
+	content = presentation.Truncate(strings.TrimRight(content, "\n"), maxCommentBody-len(header)-footer.Len())
+	return header + strings.TrimRight(content, "\n") + footer.String()
+}
+
+// commentHistory returns the history of a previous sticky comment body, which is its own run summary followed by its
+// history, limited to maxCommentHistory entries.
+func commentHistory(body string) []string {
+	history := make([]string, 0)
+	inHistory := false
+
+	for _, line := range strings.Split(body, "\n") {
+		switch {
+		case strings.HasPrefix(line, commentRunPrefix) && strings.HasSuffix(line, commentRunSuffix):
+			history = append(history, strings.TrimSuffix(strings.TrimPrefix(line, commentRunPrefix), commentRunSuffix))
+		case line == commentHistoryStart:
+			inHistory = true
+		case line == commentHistoryEnd:
+			inHistory = false
+		case inHistory && strings.HasPrefix(line, commentHistoryBullet):
+			history = append(history, strings.TrimPrefix(line, commentHistoryBullet))
+		}
+	}
+
+	return history[:min(len(history), maxCommentHistory)]
+}
+
+// minimizeComment hides a superseded comment as outdated, which is only supported by the GraphQL API.
+func minimizeComment(ctx context.Context, client *github.Client, nodeID string) error {
+	if err := queryGraphQL(ctx, client, minimizeCommentQuery, map[string]any{"id": nodeID}, nil); err != nil {
+		return fmt.Errorf("failed to minimize comment %s: %w", nodeID, err)
+	}
+
+	return nil
+}
+
+// listUnminimized returns the node IDs of the comments which are not minimized yet, which is only exposed by the
+// GraphQL API.
+func listUnminimized(ctx context.Context, client *github.Client, nodeIDs []string) ([]string, error) {
+	unminimized := make([]string, 0)
+	for start := 0; start < len(nodeIDs); start += maxNodesPerQuery {
+		data := new(struct {
+			Nodes []struct {
+				ID          string `json:"id"`
+				IsMinimized bool   `json:"isMinimized"`
+			} `json:"nodes"`
+		})
+
+		ids := nodeIDs[start:min(start+maxNodesPerQuery, len(nodeIDs))]
+		if err := queryGraphQL(ctx, client, minimizedQuery, map[string]any{"ids": ids}, data); err != nil {
+			return nil, fmt.Errorf("failed to query minimized comments: %w", err)
+		}
+
+		for _, node := range data.Nodes {
+			if node.ID != "" && !node.IsMinimized {
+				unminimized = append(unminimized, node.ID)
+			}
+		}
+	}
+
+	return unminimized, nil
+}
+
+// queryGraphQL runs a GraphQL query or mutation and decodes its data into data, unless it is nil.
+func queryGraphQL(ctx context.Context, client *github.Client, query string, variables map[string]any, data any) error {
+	req, reqErr := client.NewRequest(http.MethodPost, graphQLURL, map[string]any{
+		"query":     query,
+		"variables": variables,
+	})
+	if reqErr != nil {
+		return reqErr
+	}
+
+	resp := &struct {
+		Data   any `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{Data: data}
+
+	if _, err := client.Do(ctx, req, resp); err != nil {
+		return err
+	}
+
+	if len(resp.Errors) > 0 {
+		return errors.New(resp.Errors[0].Message)
+	}
+
+	return nil
+}
+
+// runSummary returns the one line summary of a review run of the pull request head commit.
+func runSummary(pr *pullRequest, outcome string) string {
+	sha := pr.sha
+	if len(sha) > shortSHALength {
+		sha = sha[:shortSHALength]
+	}
+
+	return fmt.Sprintf("`%s`: %s", sha, outcome)
+}
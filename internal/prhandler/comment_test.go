@@ -0,0 +1,161 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+const botLogin = "opa-reviewer[bot]"
+
+func TestUpsertComment(t *testing.T) {
+	cases := map[string]struct {
+		comments          []*github.IssueComment
+		minimize          bool
+		expectedCreated   string
+		expectedEdited    string
+		expectedMinimized []string
+	}{
+		"post new comment if no marked comment exists": {
+			comments: []*github.IssueComment{
+				{ID: github.Int64(1), Body: github.String("unrelated comment")},
+			},
+			expectedCreated: "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `1234567`: failed -->\nOutcome: failed\n",
+		},
+		"post new comment if marked comments are not posted by the bot": {
+			comments: []*github.IssueComment{
+				{ID: github.Int64(1), User: &github.User{Login: github.String(botLogin), Type: github.String("User")}, Body: github.String("<!-- opa-reviewer -->\nfake")}, // nolint: lll
+			},
+			expectedCreated: "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `1234567`: failed -->\nOutcome: failed\n",
+		},
+		"edit latest marked comment and keep previous run in history": {
+			comments: []*github.IssueComment{
+				{ID: github.Int64(1), User: getBot(), Body: github.String("<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `abc`: passed -->\nOutcome: passed\n")},           // nolint: lll
+				{ID: github.Int64(2), User: &github.User{Login: github.String("author"), Type: github.String("User")}, Body: github.String("<!-- opa-reviewer -->\nfake")}, // nolint: lll
+			},
+			expectedEdited: "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `1234567`: failed -->\nOutcome: failed\n\n<details>\n<summary>Previous reviews</summary>\n\n* `abc`: passed\n</details>\n", // nolint: lll
+		},
+		"limit history and minimize superseded comments": {
+			comments: []*github.IssueComment{
+				{ID: github.Int64(1), NodeID: github.String("node_1"), User: getBot(), Body: github.String("<!-- opa-reviewer -->\nold")},                                                                           // nolint: lll
+				{ID: github.Int64(3), NodeID: github.String("node_3"), User: &github.User{Login: github.String("other[bot]"), Type: github.String("Bot")}, Body: github.String("<!-- opa-reviewer -->\nother app")}, // nolint: lll
+				{
+					ID:     github.Int64(2),
+					NodeID: github.String("node_2"),
+					User:   getBot(),
+					Body: github.String(
+						"<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `f`: passed -->\nOutcome: passed\n\n<details>\n<summary>Previous reviews</summary>\n\n* `e`: passed\n* `d`: passed\n* `c`: passed\n* `b`: passed\n* `a`: passed\n</details>\n", // nolint: lll
+					),
+				},
+			},
+			minimize:          true,
+			expectedEdited:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `1234567`: failed -->\nOutcome: failed\n\n<details>\n<summary>Previous reviews</summary>\n\n* `f`: passed\n* `e`: passed\n* `d`: passed\n* `c`: passed\n* `b`: passed\n</details>\n", // nolint: lll
+			expectedMinimized: []string{"node_1"},
+		},
+		"skip superseded comments already minimized": {
+			comments: []*github.IssueComment{
+				{ID: github.Int64(1), NodeID: github.String("minimized_1"), User: getBot(), Body: github.String("<!-- opa-reviewer -->\nold")}, // nolint: lll
+				{ID: github.Int64(2), NodeID: github.String("node_2"), User: getBot(), Body: github.String("<!-- opa-reviewer -->\nold")},      // nolint: lll
+				{ID: github.Int64(3), NodeID: github.String("node_3"), User: getBot(), Body: github.String("<!-- opa-reviewer -->\nlatest")},   // nolint: lll
+			},
+			minimize:          true,
+			expectedEdited:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `1234567`: failed -->\nOutcome: failed\n",
+			expectedMinimized: []string{"node_2"},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var created, edited string
+			minimized := make([]string, 0)
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesCommentsByOwnerByRepoByIssueNumber, tc.comments),
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+						created = decodeComment(req).GetBody()
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PatchReposIssuesCommentsByOwnerByRepoByCommentId,
+					http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+						edited = decodeComment(req).GetBody()
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.EndpointPattern{Pattern: "/graphql", Method: http.MethodPost},
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						body := new(struct {
+							Variables struct {
+								ID  string   `json:"id"`
+								IDs []string `json:"ids"`
+							} `json:"variables"`
+						})
+						_ = json.NewDecoder(req.Body).Decode(body)
+						if body.Variables.ID == "" {
+							nodes := make([]map[string]any, 0)
+							for _, id := range body.Variables.IDs {
+								nodes = append(nodes, map[string]any{"id": id, "isMinimized": strings.HasPrefix(id, "minimized")})
+							}
+
+							_, _ = w.Write(mock.MustMarshal(map[string]any{"data": map[string]any{"nodes": nodes}}))
+							return
+						}
+
+						minimized = append(minimized, body.Variables.ID)
+						_, _ = w.Write([]byte(`{"data":{}}`))
+					}),
+				),
+			))
+
+			pr := getPullRequest()
+			pr.sha = "1234567890"
+			err := upsertComment(
+				context.TODO(),
+				client,
+				pr,
+				botLogin,
+				runSummary(pr, "failed"),
+				"Outcome: failed\n",
+				tc.minimize,
+			)
+
+			a.Nil(err)
+			a.Equal(tc.expectedCreated, created)
+			a.Equal(tc.expectedEdited, edited)
+			a.Equal(append(make([]string, 0), tc.expectedMinimized...), minimized)
+		})
+	}
+}
+
+func decodeComment(req *http.Request) *github.IssueComment {
+	defer req.Body.Close()
+	comment := new(github.IssueComment)
+	_ = json.NewDecoder(req.Body).Decode(comment)
+	return comment
+}
+
+func getBot() *github.User {
+	return &github.User{Login: github.String(botLogin), Type: github.String(botUserType)}
+}
+
+func TestRenderComment_Truncate(t *testing.T) {
+	a := assert.New(t)
+	content := strings.Repeat("* stack/app.yaml: failed\n", maxCommentBody)
+
+	body := renderComment("`1234567`: failed", content, []string{"`abc`: passed"})
+
+	a.LessOrEqual(len(body), maxCommentBody)
+	a.True(strings.HasPrefix(body, "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `1234567`: failed -->\n"))
+	a.Contains(body, "_The output is truncated as it exceeds the size accepted by GitHub._")
+	a.Equal([]string{"`1234567`: failed", "`abc`: passed"}, commentHistory(body))
+}
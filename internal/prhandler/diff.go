@@ -0,0 +1,36 @@
+package prhandler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hunkHeaderPattern matches the header of a diff hunk and captures the first line of the hunk in the new file.
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// commentableLines returns the lines of the new file which appear in the hunks of a patch, either as added or context
+// lines. GitHub only accepts review comments on those lines.
+func commentableLines(patch string) map[int]bool {
+	lines := make(map[int]bool)
+	line := 0
+
+	for _, row := range strings.Split(patch, "\n") {
+		if match := hunkHeaderPattern.FindStringSubmatch(row); match != nil {
+			line, _ = strconv.Atoi(match[1])
+			continue
+		}
+
+		if line == 0 {
+			continue
+		}
+
+		// Removed lines and "\ No newline at end of file" markers do not exist in the new file.
+		if strings.HasPrefix(row, "+") || strings.HasPrefix(row, " ") {
+			lines[line] = true
+			line++
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,33 @@
+package prhandler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentableLines(t *testing.T) {
+	cases := map[string]struct {
+		patch    string
+		expected map[int]bool
+	}{
+		"empty patch": {
+			patch:    "",
+			expected: map[int]bool{},
+		},
+		"added file": {
+			patch:    "@@ -0,0 +1,2 @@\n+a: 1\n+b: 2",
+			expected: map[int]bool{1: true, 2: true},
+		},
+		"multiple hunks with removed lines": {
+			patch:    "@@ -1,3 +1,3 @@\n a: 1\n-b: 2\n+b: 3\n c: 4\n@@ -10,2 +10,3 @@ Resources:\n d: 5\n+e: 6\n f: 7\n\\ No newline at end of file",
+			expected: map[int]bool{1: true, 2: true, 3: true, 10: true, 11: true, 12: true},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, commentableLines(tc.patch))
+		})
+	}
+}
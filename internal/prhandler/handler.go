@@ -2,33 +2,81 @@ package prhandler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
 	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
 	"github.com/CameronXie/go-opa-reviewer/internal/reader"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
 	"github.com/CameronXie/go-opa-reviewer/internal/review"
-	"github.com/bmatcuk/doublestar"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
 	"github.com/google/go-github/v58/github"
 	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
 )
 
 const (
 	pullRequestEvent  = "pull_request"
 	numResultsPerPage = 30
+	noMatchedFilesMsg = "no files matched the provided patterns"
+	botLoginSuffix    = "[bot]"
+	botUserType       = "Bot"
+
+	// maxComparisonFiles is the number of files listed by a comparison of two commits, which are only listed on its
+	// first page.
+	maxComparisonFiles = 300
 )
 
+// errTooManyFiles is returned when the files changed between two commits cannot all be listed.
+var errTooManyFiles = fmt.Errorf("%d or more files are changed, more than a comparison can list", maxComparisonFiles)
+
 type handler struct {
 	eventActivityTypes []string
-	patterns           []string
+	matcher            filematch.Matcher
 	clientCreator      githubapp.ClientCreator
 	reviewSvc          review.Service
+	prReviewer         reviewer.PullRequestReviewer
+	minimizeComments   bool
+	appLoginMu         sync.Mutex
+	appLogin           string
+}
+
+// Option configures the optional behaviours of the pull request handler.
+type Option func(*handler)
+
+// WithMinimizeOutdatedComments minimizes the superseded review comments as outdated.
+func WithMinimizeOutdatedComments(minimize bool) Option {
+	return func(h *handler) {
+		h.minimizeComments = minimize
+	}
+}
+
+// WithPullRequestReviewer reviews the metadata of the pull request, e.g. its title, labels and commit messages, and
+// reports the decision alongside the file results.
+func WithPullRequestReviewer(prReviewer reviewer.PullRequestReviewer) Option {
+	return func(h *handler) {
+		h.prReviewer = prReviewer
+	}
 }
 
 func (h *handler) Handles() []string {
-	return []string{pullRequestEvent}
+	return []string{pullRequestEvent, issueCommentEvent, checkRunEvent, checkSuiteEvent, mergeGroupEvent, pushEvent}
 }
 
 func (h *handler) Handle(ctx context.Context, eventType, _ string, payload []byte) error {
+	switch eventType {
+	case issueCommentEvent:
+		return h.handleComment(ctx, payload)
+	case checkRunEvent, checkSuiteEvent:
+		return h.handleRerun(ctx, eventType, payload)
+	case mergeGroupEvent:
+		return h.handleMergeGroup(ctx, payload)
+	case pushEvent:
+		return h.handlePush(ctx, payload)
+	}
+
 	pr, eventErr := parsePullRequestEvent(eventType, payload)
 	if eventErr != nil {
 		return eventErr
@@ -36,7 +84,7 @@ func (h *handler) Handle(ctx context.Context, eventType, _ string, payload []byt
 
 	ctx, logger := githubapp.PreparePRContext(ctx, pr.installationID, pr.repo, pr.num)
 
-	if action := pr.action; !contains(h.eventActivityTypes, action) {
+	if action := pr.action; !repoconfig.SupportsAction(action) {
 		logger.Info().Msgf("received action %s, no further processing is required", action)
 		return nil
 	}
@@ -46,29 +94,213 @@ func (h *handler) Handle(ctx context.Context, eventType, _ string, payload []byt
 		return clientErr
 	}
 
+	return h.withConfig(ctx, client, pr, func(cfg *repoconfig.Config) error {
+		if action := pr.action; !cfg.HandlesAction(action, h.eventActivityTypes) {
+			logger.Info().Msgf("received action %s, no further processing is required", action)
+			return nil
+		}
+
+		return h.review(ctx, client, pr, cfg)
+	})
+}
+
+// review reviews the changed files of a pull request, along with its metadata if a pull request reviewer is set, and
+// publishes the results.
+func (h *handler) review(ctx context.Context, client *github.Client, pr *pullRequest, cfg *repoconfig.Config) error {
+	logger := zerolog.Ctx(ctx)
+	pub := &publisher{client: client, pr: pr, cfg: cfg, minimizeComments: h.minimizeComments, botLogin: h.botLogin}
+
+	logger.Debug().Msgf("creating check run on %s", pr.getPullRequestString())
+	if err := pub.start(ctx); err != nil {
+		return err
+	}
+
+	logger.Debug().Msgf("resolving merge base of %s", pr.getPullRequestString())
+	if err := pr.resolveMergeBase(ctx, client); err != nil {
+		return pub.fail(ctx, err)
+	}
+
 	logger.Debug().Msgf("fetching changed files from %s", pr.getPullRequestString())
 	files, filesErr := getChangedFiles(ctx, client, pr.getOwner(), pr.getRepoName(), pr.num)
 	if filesErr != nil {
-		return filesErr
+		return pub.fail(ctx, filesErr)
 	}
 
-	fileNames := getMatchingFileNames(files, h.patterns)
-	if len(fileNames) == 0 {
-		return postComment(ctx, client, pr.getOwner(), pr.getRepoName(), pr.num, "no files matched the provided patterns")
+	changedFiles := toChangedFiles(files)
+	matchedFiles := getMatchingFiles(changedFiles, h.matcher, cfg)
+	if len(matchedFiles) == 0 && h.prReviewer == nil {
+		return pub.publish(ctx, noMatchedFilesMsg, &checkRunOutput{
+			conclusion: presentation.ConclusionSuccess,
+			title:      noMatchedFilesMsg,
+			summary:    noMatchedFilesMsg,
+		})
+	}
+
+	results := make([]review.Result, 0)
+	if len(matchedFiles) > 0 {
+		logger.Debug().Msgf("reviewing %d changed files from %s", len(matchedFiles), pr.getPullRequestString())
+		fileResults, reviewErr := h.reviewSvc.Review(
+			ctx,
+			reader.ReadGitHubFile(client, pr.getOwner(), pr.getRepoName(), pr.sha),
+			matchedFiles,
+			review.WithBase(reader.ReadGitHubFile(client, pr.getOwner(), pr.getRepoName(), pr.baseSHA)),
+			review.WithChangedFiles(changedFiles),
+			review.WithEnvelope(pr.envelope()),
+		)
+		if reviewErr != nil {
+			return pub.fail(ctx, reviewErr)
+		}
+
+		results = fileResults
+	}
+
+	if h.prReviewer != nil {
+		logger.Debug().Msgf("reviewing metadata of %s", pr.getPullRequestString())
+		results = append(results, reviewPullRequest(ctx, client, pr, h.prReviewer))
+	}
+
+	logger.Debug().Msgf("publishing results on %s", pr.getPullRequestString())
+	return pub.publishResults(ctx, cfg.Apply(results), getPatches(files))
+}
+
+// reviewComparison reviews the files changed between the base and the head commits of the target, and returns the
+// results with the repository configuration applied, or nil if no file is matched. errTooManyFiles is returned rather
+// than reviewing part of the files if the comparison lists as many files as it can.
+func (h *handler) reviewComparison(
+	ctx context.Context,
+	client *github.Client,
+	target *pullRequest,
+	cfg *repoconfig.Config,
+) ([]review.Result, error) {
+	comparison, _, compareErr := client.Repositories.CompareCommits(
+		ctx,
+		target.getOwner(),
+		target.getRepoName(),
+		target.baseSHA,
+		target.sha,
+		nil,
+	)
+	if compareErr != nil {
+		return nil, compareErr
+	}
+
+	if len(comparison.Files) >= maxComparisonFiles {
+		return nil, errTooManyFiles
+	}
+
+	changedFiles := toChangedFiles(comparison.Files)
+	matchedFiles := getMatchingFiles(changedFiles, h.matcher, cfg)
+	if len(matchedFiles) == 0 {
+		return nil, nil
+	}
+
+	// The base files are read at the merge base, which differs from the base commit when the head does not descend
+	// from it, e.g. after a force push.
+	mergeBase := target.baseSHA
+	if sha := comparison.GetMergeBaseCommit().GetSHA(); sha != "" {
+		mergeBase = sha
 	}
 
-	logger.Debug().Msgf("reviewing %d changed files from %s", len(fileNames), pr.getPullRequestString())
 	results, reviewErr := h.reviewSvc.Review(
 		ctx,
-		reader.ReadGitHubFile(client, pr.getOwner(), pr.getRepoName(), pr.sha),
-		fileNames,
+		reader.ReadGitHubFile(client, target.getOwner(), target.getRepoName(), target.sha),
+		matchedFiles,
+		review.WithBase(reader.ReadGitHubFile(client, target.getOwner(), target.getRepoName(), mergeBase)),
+		review.WithChangedFiles(changedFiles),
+		review.WithEnvelope(target.envelope()),
 	)
 	if reviewErr != nil {
-		return reviewErr
+		return nil, reviewErr
+	}
+
+	return cfg.Apply(results), nil
+}
+
+// botLogin returns the login of the bot user of the app, e.g. opa-reviewer[bot], which authors its comments. It is
+// looked up once with the app client.
+func (h *handler) botLogin(ctx context.Context) (string, error) {
+	h.appLoginMu.Lock()
+	defer h.appLoginMu.Unlock()
+
+	if h.appLogin != "" {
+		return h.appLogin, nil
+	}
+
+	client, clientErr := h.clientCreator.NewAppClient()
+	if clientErr != nil {
+		return "", clientErr
+	}
+
+	app, _, appErr := client.Apps.Get(ctx, "")
+	if appErr != nil {
+		return "", appErr
 	}
 
-	logger.Debug().Msgf("posting comment on %s", pr.getPullRequestString())
-	return postComment(ctx, client, pr.getOwner(), pr.getRepoName(), pr.num, presentation.Markdown(results))
+	h.appLogin = app.GetSlug() + botLoginSuffix
+	return h.appLogin, nil
+}
+
+// withConfig runs fn with the repository configuration, which is read from the default branch. A malformed
+// configuration is reported on the pull request instead, unless the action is one which only triggers a review when
+// configured, e.g. labeled, since the configuration cannot tell whether it does.
+func (h *handler) withConfig(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	fn func(cfg *repoconfig.Config) error,
+) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msgf("loading %s of %s", repoconfig.Path, pr.repo.GetFullName())
+	cfg, cfgErr := repoconfig.Load(ctx, client, pr.getOwner(), pr.getRepoName(), pr.defaultBranch)
+
+	var validationErr *repoconfig.ValidationError
+	if errors.As(cfgErr, &validationErr) && !h.reportsConfigError(pr.action) {
+		logger.Info().Msgf("ignoring invalid configuration on action %s of %s", pr.action, pr.getPullRequestString())
+		return nil
+	}
+
+	if validationErr != nil {
+		logger.Info().Msgf("reporting invalid configuration on %s", pr.getPullRequestString())
+		return h.reportConfigError(ctx, client, pr, validationErr)
+	}
+
+	if cfgErr != nil {
+		return cfgErr
+	}
+
+	return fn(cfg)
+}
+
+// reportsConfigError reports whether a malformed configuration is reported for the action, which is the case for the
+// pull request actions handled by default, and for the commands and re-runs, which are explicitly requested.
+func (h *handler) reportsConfigError(action string) bool {
+	return !repoconfig.SupportsAction(action) || contains(h.eventActivityTypes, action)
+}
+
+// reportConfigError reports a malformed repository configuration on the pull request with all outputs enabled.
+func (h *handler) reportConfigError(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	cfgErr *repoconfig.ValidationError,
+) error {
+	pub := &publisher{
+		client:           client,
+		pr:               pr,
+		cfg:              new(repoconfig.Config),
+		minimizeComments: h.minimizeComments,
+		botLogin:         h.botLogin,
+	}
+	if err := pub.start(ctx); err != nil {
+		return err
+	}
+
+	msg := "invalid configuration"
+	return pub.publish(ctx, msg, &checkRunOutput{
+		conclusion: presentation.ConclusionFailure,
+		title:      msg,
+		summary:    cfgErr.Error(),
+	})
 }
 
 // parsePullRequestEvent parses a pull request event and returns the corresponding *pullRequest object
@@ -84,13 +316,12 @@ func parsePullRequestEvent(eventType string, payload []byte) (*pullRequest, erro
 		return nil, fmt.Errorf("unexpected event payload type %s found", eventType)
 	}
 
-	return &pullRequest{
-		num:            prEvent.GetPullRequest().GetNumber(),
-		repo:           prEvent.GetRepo(),
-		sha:            prEvent.GetPullRequest().GetHead().GetSHA(),
-		action:         prEvent.GetAction(),
-		installationID: prEvent.GetInstallation().GetID(),
-	}, nil
+	return newPullRequest(
+		prEvent.GetPullRequest(),
+		prEvent.GetRepo(),
+		prEvent.GetInstallation().GetID(),
+		prEvent.GetAction(),
+	), nil
 }
 
 // getChangedFiles retrieves the list of changed files in a pull request.
@@ -124,45 +355,38 @@ func getChangedFiles(
 	return commitFiles, nil
 }
 
-// getMatchingFileNames returns a list of file names from the given list of commit files
-// that match any of the provided patterns.
-func getMatchingFileNames(files []*github.CommitFile, patterns []string) []string {
-	fileNames := make([]string, 0)
+// toChangedFiles converts the commit files of a pull request into changed files.
+func toChangedFiles(files []*github.CommitFile) []review.ChangedFile {
+	changed := make([]review.ChangedFile, 0, len(files))
 	for idx := range files {
-		fileName := files[idx].GetFilename()
-		if isMatchedFile(fileName, patterns) {
-			fileNames = append(fileNames, fileName)
-		}
+		changed = append(changed, review.ChangedFile{
+			Name:         files[idx].GetFilename(),
+			Status:       files[idx].GetStatus(),
+			PreviousName: files[idx].GetPreviousFilename(),
+			Additions:    files[idx].GetAdditions(),
+			Deletions:    files[idx].GetDeletions(),
+		})
 	}
 
-	return fileNames
+	return changed
 }
 
-// isMatchedFile checks if a given file name matches any of the glob patterns provided.
-func isMatchedFile(fileName string, patterns []string) bool {
-	for _, path := range patterns {
-		if matched, _ := doublestar.PathMatch(path, fileName); matched {
-			return true
+// getMatchingFiles returns the changed files which are matched by the matcher, and by the repository configuration.
+func getMatchingFiles(
+	files []review.ChangedFile,
+	matcher filematch.Matcher,
+	cfg *repoconfig.Config,
+) []review.ChangedFile {
+	matched := make([]review.ChangedFile, 0)
+	for _, file := range files {
+		if matcher.Match(file.Name, file.Status) && cfg.Matches(file.Name, file.Status) {
+			matched = append(matched, file)
 		}
 	}
 
-	return false
+	return matched
 }
 
-func postComment(
-	ctx context.Context,
-	client *github.Client,
-	owner string,
-	repo string,
-	num int,
-	content string,
-) error {
-	_, _, err := client.Issues.CreateComment(ctx, owner, repo, num, &github.IssueComment{
-		Body: github.String(content),
-	})
-
-	return err
-}
 func contains[T comparable](s []T, item T) bool {
 	for idx := range s {
 		if s[idx] == item {
@@ -173,11 +397,22 @@ func contains[T comparable](s []T, item T) bool {
 	return false
 }
 
-func New(clientCreator githubapp.ClientCreator, patterns []string, reviewSvc review.Service) githubapp.EventHandler {
-	return &handler{
+func New(
+	clientCreator githubapp.ClientCreator,
+	matcher filematch.Matcher,
+	reviewSvc review.Service,
+	opts ...Option,
+) githubapp.EventHandler {
+	h := &handler{
 		clientCreator:      clientCreator,
-		patterns:           patterns,
+		matcher:            matcher,
 		reviewSvc:          reviewSvc,
 		eventActivityTypes: []string{"opened", "reopened", "synchronize", "ready_for_review"},
 	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
 }
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"io"
 	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
 	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
 	"github.com/google/go-github/v58/github"
 	"github.com/migueleliasweb/go-github-mock/src/mock"
 	"github.com/palantir/go-githubapp/githubapp"
@@ -30,42 +32,69 @@ func (m *mockClientCreator) NewInstallationClient(_ int64) (*github.Client, erro
 	return m.client, nil
 }
 
+func (m *mockClientCreator) NewAppClient() (*github.Client, error) {
+	return m.NewInstallationClient(0)
+}
+
 type mockReviewSvc struct {
 	err error
 }
 
-func (m *mockReviewSvc) Review(_ context.Context, _ review.ReadFileFunc, files []string) ([]review.Result, error) {
+func (m *mockReviewSvc) Review(
+	_ context.Context,
+	_ review.ReadFileFunc,
+	files []review.ChangedFile,
+	_ ...review.Option,
+) ([]review.Result, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
 
 	res := make([]review.Result, 0)
 	for _, file := range files {
-		if strings.Contains(file, "invalid") {
+		if strings.Contains(file.Name, "invalid") {
 			res = append(res, review.Result{
-				File:  file,
+				File:  file.Name,
 				Error: errors.New("invalid file"),
 			})
 			continue
 		}
 
 		res = append(res, review.Result{
-			File:   file,
-			Output: []byte("valid file"),
+			File:     file.Name,
+			Decision: &reviewer.Decision{Allow: true},
 		})
 	}
 
 	return res, m.err
 }
 
+type mockPullRequestReviewer struct {
+	pr *reviewer.PullRequest
+}
+
+func (m *mockPullRequestReviewer) Review(_ context.Context, pr *reviewer.PullRequest) (*reviewer.Decision, error) {
+	m.pr = pr
+	return &reviewer.Decision{
+		Allow:      false,
+		Violations: []reviewer.Violation{{RuleID: "rule_1", Severity: reviewer.SeverityError, Message: "violation_1"}},
+		Documents:  1,
+	}, nil
+}
+
 func TestHandler_Handle(t *testing.T) {
 	cases := map[string]struct {
 		payload             []byte
 		installClientErr    error
 		listChangedFiles    []string
+		listRemovedFiles    []string
 		listChangedFilesErr bool
 		reviewErr           error
+		prReviewer          reviewer.PullRequestReviewer
+		config              *string
+		createCheckRunErr   bool
 		expectedComment     string
+		expectedConclusion  string
 		expectedErrMsg      *string
 	}{
 		"review files and post results in comment": {
@@ -75,12 +104,79 @@ func TestHandler_Handle(t *testing.T) {
 				"stack/file_2.yaml",
 				"stack/app/invalid_file_3.yaml",
 			},
-			expectedComment: "{\"body\":\"Reviews:\\n* stack/file_2.yaml: valid file\\n\\nErrors:\\n* stack/app/invalid_file_3.yaml: invalid file\\n\"}\n", // nolint: lll
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: passed -->\nOutcome: passed\n\nReviews:\n* stack/file_2.yaml: passed\n\nErrors:\n* stack/app/invalid_file_3.yaml: invalid file\n", // nolint: lll
+			expectedConclusion: "failure",
+		},
+		"review files and complete check run as success": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"stack/file_1.yaml"},
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: passed -->\nOutcome: passed\n\nReviews:\n* stack/file_1.yaml: passed\n", // nolint: lll
+			expectedConclusion: "success",
+		},
+		"review pull request metadata along with files": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"stack/file_1.yaml"},
+			prReviewer:         new(mockPullRequestReviewer),
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: failed -->\nOutcome: failed\n\nReviews:\n* stack/file_1.yaml: passed\n* pull_request: failed\n  * [error] rule_1: violation_1\n", // nolint: lll
+			expectedConclusion: "failure",
+		},
+		"review pull request metadata when no matching file found": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"file_1.yaml"},
+			prReviewer:         new(mockPullRequestReviewer),
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: failed -->\nOutcome: failed\n\nReviews:\n* pull_request: failed\n  * [error] rule_1: violation_1\n", // nolint: lll
+			expectedConclusion: "failure",
 		},
 		"no matching file found and post msg in comment": {
-			payload:          getPullRequestPayload("synchronize"),
-			listChangedFiles: []string{"file_1.yaml"},
-			expectedComment:  "{\"body\":\"no files matched the provided patterns\"}\n",
+			payload:            getPullRequestPayload("synchronize"),
+			listChangedFiles:   []string{"file_1.yaml"},
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: no files matched the provided patterns -->\nno files matched the provided patterns", // nolint: lll
+			expectedConclusion: "success",
+		},
+		"skip removed files": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"stack/file_1.yaml"},
+			listRemovedFiles:   []string{"stack/file_2.yaml"},
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: passed -->\nOutcome: passed\n\nReviews:\n* stack/file_1.yaml: passed\n", // nolint: lll
+			expectedConclusion: "success",
+		},
+		"exclude files by negated repository config rule": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"stack/file_1.yaml"},
+			config:             strPtr("include: [stack/**, '!stack/file_1.yaml']"),
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: no files matched the provided patterns -->\nno files matched the provided patterns", // nolint: lll
+			expectedConclusion: "success",
+		},
+		"exclude files by repository config": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"stack/file_1.yaml"},
+			config:             strPtr("exclude: [stack/file_1.yaml]"),
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: no files matched the provided patterns -->\nno files matched the provided patterns", // nolint: lll
+			expectedConclusion: "success",
+		},
+		"handle actions and outputs of repository config": {
+			payload:          getPullRequestPayload("labeled"),
+			listChangedFiles: []string{"stack/file_1.yaml"},
+			config:           strPtr("actions: [labeled]\noutputs: [comment]"),
+			expectedComment:  "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: passed -->\nOutcome: passed\n\nReviews:\n* stack/file_1.yaml: passed\n", // nolint: lll
+		},
+		"report invalid repository config": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"stack/file_1.yaml"},
+			config:             strPtr("outputs: [email]"),
+			expectedComment:    "<!-- opa-reviewer -->\n<!-- opa-reviewer-run: `12345`: invalid configuration -->\ninvalid .github/opa-reviewer.yml: outputs[0]: must be one of check_run, comment, review", // nolint: lll
+			expectedConclusion: "failure",
+		},
+		"ignore invalid repository config on action not handled by default": {
+			payload:          getPullRequestPayload("labeled"),
+			listChangedFiles: []string{"stack/file_1.yaml"},
+			config:           strPtr("outputs: [email]"),
+			expectedComment:  "",
+		},
+		"ignore closed event type": {
+			payload:          getPullRequestPayload("closed"),
+			listChangedFiles: []string{"stack/file_1.yaml"},
+			expectedComment:  "",
 		},
 		"ignore untrack event type and not post msg in comment": {
 			payload:          getPullRequestPayload("labeled"),
@@ -96,16 +192,23 @@ func TestHandler_Handle(t *testing.T) {
 			installClientErr: errors.New("failed to setup client"),
 			expectedErrMsg:   strPtr("failed to setup client"),
 		},
-		"failed to list commit files should return error": {
+		"failed to create check run should return error": {
+			payload:           getPullRequestPayload("opened"),
+			createCheckRunErr: true,
+			expectedErrMsg:    strPtr("repos/owner/repo/check-runs: 400 bad request"),
+		},
+		"failed to list commit files should fail check run and return error": {
 			payload:             getPullRequestPayload("opened"),
 			listChangedFilesErr: true,
+			expectedConclusion:  "failure",
 			expectedErrMsg:      strPtr("repos/owner/repo/pulls/2/files?per_page=30: 400 bad request"),
 		},
-		"failed to review files should return error": {
-			payload:          getPullRequestPayload("opened"),
-			listChangedFiles: []string{"stack/file_1.yaml"},
-			reviewErr:        errors.New("failed to review files"),
-			expectedErrMsg:   strPtr("failed to review files"),
+		"failed to review files should fail check run and return error": {
+			payload:            getPullRequestPayload("opened"),
+			listChangedFiles:   []string{"stack/file_1.yaml"},
+			reviewErr:          errors.New("failed to review files"),
+			expectedConclusion: "failure",
+			expectedErrMsg:     strPtr("failed to review files"),
 		},
 	}
 
@@ -113,10 +216,11 @@ func TestHandler_Handle(t *testing.T) {
 		t.Run(n, func(t *testing.T) {
 			a := assert.New(t)
 			var sb strings.Builder
+			var conclusion string
 
 			listFileMock := mock.WithRequestMatch(
 				mock.GetReposPullsFilesByOwnerByRepoByPullNumber,
-				toCommitFiles(tc.listChangedFiles),
+				append(toCommitFiles(tc.listChangedFiles, "modified"), toCommitFiles(tc.listRemovedFiles, "removed")...),
 			)
 
 			if tc.listChangedFilesErr {
@@ -132,27 +236,113 @@ func TestHandler_Handle(t *testing.T) {
 				)
 			}
 
+			createCheckRunMock := mock.WithRequestMatch(
+				mock.PostReposCheckRunsByOwnerByRepo,
+				github.CheckRun{ID: github.Int64(1)},
+			)
+
+			if tc.createCheckRunErr {
+				createCheckRunMock = mock.WithRequestMatchHandler(
+					mock.PostReposCheckRunsByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						mock.WriteError(
+							w,
+							http.StatusBadRequest,
+							"bad request",
+						)
+					}),
+				)
+			}
+
+			configMock := mock.WithRequestMatchHandler(
+				mock.GetReposContentsByOwnerByRepoByPath,
+				http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+					if req.URL.Query().Get("ref") != "main" {
+						mock.WriteError(w, http.StatusBadRequest, "config must be read from the default branch")
+						return
+					}
+
+					if tc.config == nil {
+						mock.WriteError(w, http.StatusNotFound, "not found")
+						return
+					}
+
+					_, _ = w.Write(mock.MustMarshal(github.RepositoryContent{Content: tc.config}))
+				}),
+			)
+
 			client := github.NewClient(mock.NewMockedHTTPClient(
 				listFileMock,
+				configMock,
+				createCheckRunMock,
+				mock.WithRequestMatchHandler(
+					mock.GetReposCompareByOwnerByRepoByBasehead,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						if req.URL.Path != "/repos/owner/repo/compare/67890...12345" {
+							mock.WriteError(w, http.StatusBadRequest, "base and head of pull request must be compared")
+							return
+						}
+
+						_, _ = w.Write(mock.MustMarshal(github.CommitsComparison{
+							MergeBaseCommit: &github.RepositoryCommit{SHA: github.String("abcde")},
+						}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PatchReposCheckRunsByOwnerByRepoByCheckRunId,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						opts := new(github.UpdateCheckRunOptions)
+						_ = json.NewDecoder(req.Body).Decode(opts)
+						conclusion = opts.GetConclusion()
+						_, _ = w.Write(mock.MustMarshal(github.CheckRun{ID: github.Int64(1)}))
+					}),
+				),
+				mock.WithRequestMatch(
+					mock.GetReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					make([]*github.IssueComment, 0),
+				),
+				mock.WithRequestMatch(mock.GetApp, github.App{Slug: github.String("opa-reviewer")}),
+				mock.WithRequestMatch(
+					mock.GetReposPullsCommitsByOwnerByRepoByPullNumber,
+					make([]*github.RepositoryCommit, 0),
+				),
+				mock.WithRequestMatch(
+					mock.EndpointPattern{Pattern: "/graphql", Method: http.MethodPost},
+					map[string]any{"data": map[string]any{}},
+				),
 				mock.WithRequestMatchHandler(
 					mock.PostReposIssuesCommentsByOwnerByRepoByIssueNumber,
 					http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
 						defer req.Body.Close()
-						content, _ := io.ReadAll(req.Body)
-						sb.Write(content)
+						comment := new(github.IssueComment)
+						_ = json.NewDecoder(req.Body).Decode(comment)
+						sb.WriteString(comment.GetBody())
 					}),
 				),
 			))
 
 			h := New(
 				&mockClientCreator{client: client, clientErr: tc.installClientErr},
-				[]string{"stack/**/*.yaml"},
+				filematch.New([]string{"stack/**/*.yaml", "!removed:**"}),
 				&mockReviewSvc{err: tc.reviewErr},
+				WithPullRequestReviewer(tc.prReviewer),
 			)
 
-			a.EqualValues([]string{pullRequestEvent}, h.Handles())
+			a.EqualValues(
+				[]string{
+					pullRequestEvent,
+					issueCommentEvent,
+					checkRunEvent,
+					checkSuiteEvent,
+					mergeGroupEvent,
+					pushEvent,
+				},
+				h.Handles(),
+			)
 			err := h.Handle(context.TODO(), pullRequestEvent, "", tc.payload)
 
+			a.Equal(tc.expectedConclusion, conclusion)
 			if tc.expectedErrMsg != nil {
 				a.Contains(err.Error(), *tc.expectedErrMsg)
 				return
@@ -164,12 +354,57 @@ func TestHandler_Handle(t *testing.T) {
 	}
 }
 
+func TestToChangedFiles(t *testing.T) {
+	files := []*github.CommitFile{
+		{
+			Filename:         github.String("stack/app.yaml"),
+			PreviousFilename: github.String("stack/legacy.yaml"),
+			Status:           github.String("renamed"),
+			Additions:        github.Int(2),
+			Deletions:        github.Int(1),
+		},
+		{Filename: github.String("stack/db.yaml"), Status: github.String("removed"), Deletions: github.Int(20)},
+		{Filename: github.String("docs/README.md"), Status: github.String("modified")},
+	}
+
+	changed := toChangedFiles(files)
+	assert.Equal(
+		t,
+		[]review.ChangedFile{
+			{Name: "stack/app.yaml", Status: "renamed", PreviousName: "stack/legacy.yaml", Additions: 2, Deletions: 1},
+			{Name: "stack/db.yaml", Status: "removed", Deletions: 20},
+			{Name: "docs/README.md", Status: "modified"},
+		},
+		changed,
+	)
+	assert.Equal(t, changed[:2], getMatchingFiles(changed, filematch.New([]string{"stack/**"}), new(repoconfig.Config)))
+}
+
+func TestPullRequest_Envelope(t *testing.T) {
+	pr, err := parsePullRequestEvent(pullRequestEvent, getPullRequestPayload("opened"))
+	assert.NoError(t, err)
+	assert.Equal(t, reviewer.Envelope{
+		Repository:  "owner/repo",
+		BaseRef:     "main",
+		HeadSHA:     "12345",
+		PullRequest: 2,
+		Author:      "author",
+	}, pr.envelope())
+}
+
 func getPullRequestPayload(action string) []byte {
 	payload := map[string]any{
 		"action": action,
 		"number": 1,
 		"pull_request": map[string]any{
 			"number": 2,
+			"base": map[string]any{
+				"sha": "67890",
+				"ref": "main",
+			},
+			"user": map[string]any{
+				"login": "author",
+			},
 			"head": map[string]any{
 				"sha": "12345",
 				"user": map[string]any{
@@ -178,9 +413,10 @@ func getPullRequestPayload(action string) []byte {
 			},
 		},
 		"repository": map[string]any{
-			"id":        12345678,
-			"name":      "repo",
-			"full_name": "owner/repo",
+			"id":             12345678,
+			"name":           "repo",
+			"full_name":      "owner/repo",
+			"default_branch": "main",
 			"owner": map[string]any{
 				"login": "owner",
 			},
@@ -194,10 +430,10 @@ func getPullRequestPayload(action string) []byte {
 	return bs
 }
 
-func toCommitFiles(s []string) []*github.CommitFile {
+func toCommitFiles(s []string, status string) []*github.CommitFile {
 	commitFiles := make([]*github.CommitFile, 0, len(s))
 	for _, file := range s {
-		commitFiles = append(commitFiles, &github.CommitFile{Filename: github.String(file)})
+		commitFiles = append(commitFiles, &github.CommitFile{Filename: github.String(file), Status: github.String(status)})
 	}
 	return commitFiles
 }
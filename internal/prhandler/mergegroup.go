@@ -0,0 +1,134 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+)
+
+const (
+	mergeGroupEvent       = "merge_group"
+	checksRequestedAction = "checks_requested"
+	branchRefPrefix       = "refs/heads/"
+	tooManyFilesMsg       = "too many changed files"
+)
+
+// handleMergeGroup reviews the files changed by a merge group of a merge queue, and reports the results as a check run
+// on its head commit, so the check required by the branch protection rules is reported for the queued pull requests.
+func (h *handler) handleMergeGroup(ctx context.Context, payload []byte) error {
+	group, eventErr := parseMergeGroupEvent(payload)
+	if eventErr != nil {
+		return eventErr
+	}
+
+	ctx, logger := githubapp.PrepareRepoContext(ctx, group.installationID, group.repo)
+	if group.action != checksRequestedAction {
+		logger.Info().Msgf("received action %s, no further processing is required", group.action)
+		return nil
+	}
+
+	client, clientErr := h.clientCreator.NewInstallationClient(group.installationID)
+	if clientErr != nil {
+		return clientErr
+	}
+
+	logger.Debug().Msgf("loading %s of %s", repoconfig.Path, group.repo.GetFullName())
+	cfg, cfgErr := repoconfig.Load(ctx, client, group.getOwner(), group.getRepoName(), group.defaultBranch)
+
+	var validationErr *repoconfig.ValidationError
+	if cfgErr != nil && !errors.As(cfgErr, &validationErr) {
+		return cfgErr
+	}
+
+	if validationErr == nil && !cfg.HasOutput(repoconfig.OutputCheckRun) {
+		logger.Info().Msg("check runs are disabled, no further processing is required")
+		return nil
+	}
+
+	logger.Debug().Msgf("creating check run on merge group %s", group.sha)
+	id, checkRunErr := createCheckRun(ctx, client, group)
+	if checkRunErr != nil {
+		return checkRunErr
+	}
+
+	if validationErr != nil {
+		msg := "invalid configuration"
+		return completeCheckRun(ctx, client, group, id, &checkRunOutput{
+			conclusion: presentation.ConclusionFailure,
+			title:      msg,
+			summary:    validationErr.Error(),
+		})
+	}
+
+	output, reviewErr := h.reviewMergeGroup(ctx, client, group, cfg)
+	if reviewErr != nil {
+		return failCheckRun(ctx, client, group, id, reviewErr)
+	}
+
+	return completeCheckRun(ctx, client, group, id, output)
+}
+
+// reviewMergeGroup reviews the files changed between the base and the head commits of the merge group. The review fails
+// if the changed files cannot all be listed, so that no file is merged unreviewed.
+func (h *handler) reviewMergeGroup(
+	ctx context.Context,
+	client *github.Client,
+	group *pullRequest,
+	cfg *repoconfig.Config,
+) (*checkRunOutput, error) {
+	results, reviewErr := h.reviewComparison(ctx, client, group, cfg)
+	if errors.Is(reviewErr, errTooManyFiles) {
+		return &checkRunOutput{
+			conclusion: presentation.ConclusionFailure,
+			title:      tooManyFilesMsg,
+			summary:    fmt.Sprintf("The merge group is not reviewed, as %s.", reviewErr),
+		}, nil
+	}
+
+	if reviewErr != nil {
+		return nil, reviewErr
+	}
+
+	if results == nil {
+		return &checkRunOutput{
+			conclusion: presentation.ConclusionSuccess,
+			title:      noMatchedFilesMsg,
+			summary:    noMatchedFilesMsg,
+		}, nil
+	}
+
+	return newCheckRunOutput(results), nil
+}
+
+// parseMergeGroupEvent parses a merge group event. The merge group is returned as a pull request without number,
+// whose head and base are the ones of the merge group.
+func parseMergeGroupEvent(payload []byte) (*pullRequest, error) {
+	event, err := github.ParseWebHook(mergeGroupEvent, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	groupEvent, ok := event.(*github.MergeGroupEvent)
+	if !ok {
+		return nil, fmt.Errorf("unexpected event payload type %s found", mergeGroupEvent)
+	}
+
+	group := groupEvent.GetMergeGroup()
+	return &pullRequest{
+		repo:           groupEvent.GetRepo(),
+		sha:            group.GetHeadSHA(),
+		baseSHA:        group.GetBaseSHA(),
+		action:         groupEvent.GetAction(),
+		defaultBranch:  groupEvent.GetRepo().GetDefaultBranch(),
+		installationID: groupEvent.GetInstallation().GetID(),
+		details: &github.PullRequest{
+			Base: &github.PullRequestBranch{Ref: github.String(strings.TrimPrefix(group.GetBaseRef(), branchRefPrefix))},
+		},
+	}, nil
+}
@@ -0,0 +1,194 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Handle_MergeGroup(t *testing.T) {
+	cases := map[string]struct {
+		payload            []byte
+		files              []string
+		config             string
+		reviewErr          error
+		expectedHeadSHA    string
+		expectedBasehead   string
+		expectedConclusion string
+		expectedTitle      string
+		expectedErrMsg     string
+	}{
+		"review files of merge group": {
+			payload:            getMergeGroupPayload(checksRequestedAction),
+			files:              []string{"stack/file_1.yaml", "docs/README.md"},
+			expectedHeadSHA:    "abcdef",
+			expectedBasehead:   "67890...abcdef",
+			expectedConclusion: "success",
+			expectedTitle:      "1 files reviewed, 0 violations found",
+		},
+		"report no matched files of merge group": {
+			payload:            getMergeGroupPayload(checksRequestedAction),
+			files:              []string{"docs/README.md"},
+			expectedHeadSHA:    "abcdef",
+			expectedBasehead:   "67890...abcdef",
+			expectedConclusion: "success",
+			expectedTitle:      noMatchedFilesMsg,
+		},
+		"report invalid configuration": {
+			payload:            getMergeGroupPayload(checksRequestedAction),
+			config:             "outputs: [email]",
+			expectedHeadSHA:    "abcdef",
+			expectedConclusion: "failure",
+			expectedTitle:      "invalid configuration",
+		},
+		"skip merge group when check runs are disabled": {
+			payload: getMergeGroupPayload(checksRequestedAction),
+			config:  "outputs: [comment]",
+		},
+		"ignore destroyed merge group": {
+			payload: getMergeGroupPayload("destroyed"),
+		},
+		"fail check run when changed files cannot all be listed": {
+			payload:            getMergeGroupPayload(checksRequestedAction),
+			files:              getFileNames(maxComparisonFiles),
+			expectedHeadSHA:    "abcdef",
+			expectedBasehead:   "67890...abcdef",
+			expectedConclusion: "failure",
+			expectedTitle:      tooManyFilesMsg,
+		},
+		"fail check run when review failed": {
+			payload:            getMergeGroupPayload(checksRequestedAction),
+			files:              []string{"stack/file_1.yaml"},
+			reviewErr:          errors.New("review failed"),
+			expectedHeadSHA:    "abcdef",
+			expectedBasehead:   "67890...abcdef",
+			expectedConclusion: "failure",
+			expectedTitle:      "review failed",
+			expectedErrMsg:     "review failed",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var headSHA, basehead, conclusion, title string
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposContentsByOwnerByRepoByPath,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						if tc.config == "" {
+							mock.WriteError(w, http.StatusNotFound, "not found")
+							return
+						}
+
+						_, _ = w.Write(mock.MustMarshal(github.RepositoryContent{Content: github.String(tc.config)}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.GetReposCompareByOwnerByRepoByBasehead,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						basehead = req.URL.Path[len("/repos/owner/repo/compare/"):]
+						_, _ = w.Write(mock.MustMarshal(github.CommitsComparison{
+							Files: toCommitFiles(tc.files, "modified"),
+						}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PostReposCheckRunsByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						opts := new(github.CreateCheckRunOptions)
+						_ = json.NewDecoder(req.Body).Decode(opts)
+						headSHA = opts.HeadSHA
+						_, _ = w.Write(mock.MustMarshal(github.CheckRun{ID: github.Int64(1)}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PatchReposCheckRunsByOwnerByRepoByCheckRunId,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						opts := new(github.UpdateCheckRunOptions)
+						_ = json.NewDecoder(req.Body).Decode(opts)
+						conclusion = opts.GetConclusion()
+						title = opts.GetOutput().GetTitle()
+						_, _ = w.Write(mock.MustMarshal(github.CheckRun{ID: github.Int64(1)}))
+					}),
+				),
+			))
+
+			h := New(
+				&mockClientCreator{client: client},
+				filematch.New([]string{"stack/**/*.yaml"}),
+				&mockReviewSvc{err: tc.reviewErr},
+			)
+
+			err := h.Handle(context.TODO(), mergeGroupEvent, "", tc.payload)
+
+			a.Equal(tc.expectedHeadSHA, headSHA)
+			a.Equal(tc.expectedBasehead, basehead)
+			a.Equal(tc.expectedConclusion, conclusion)
+			a.Equal(tc.expectedTitle, title)
+			if tc.expectedErrMsg != "" {
+				a.ErrorContains(err, tc.expectedErrMsg)
+				return
+			}
+
+			a.Nil(err)
+		})
+	}
+}
+
+func TestParseMergeGroupEvent(t *testing.T) {
+	a := assert.New(t)
+	group, err := parseMergeGroupEvent(getMergeGroupPayload(checksRequestedAction))
+
+	a.Nil(err)
+	a.Equal("abcdef", group.sha)
+	a.Equal("67890", group.baseSHA)
+	a.Equal("main", group.envelope().BaseRef)
+	a.Equal(0, group.envelope().PullRequest)
+}
+
+func getMergeGroupPayload(action string) []byte {
+	payload := map[string]any{
+		"action": action,
+		"merge_group": map[string]any{
+			"head_sha": "abcdef",
+			"head_ref": "refs/heads/gh-readonly-queue/main/pr-2-12345",
+			"base_sha": "67890",
+			"base_ref": "refs/heads/main",
+		},
+		"repository": map[string]any{
+			"name":           "repo",
+			"full_name":      "owner/repo",
+			"default_branch": "main",
+			"owner": map[string]any{
+				"login": "owner",
+			},
+		},
+		"installation": map[string]any{
+			"id": 12345678,
+		},
+	}
+
+	bs, _ := json.Marshal(payload)
+	return bs
+}
+
+func getFileNames(num int) []string {
+	names := make([]string, 0, num)
+	for idx := 0; idx < num; idx++ {
+		names = append(names, fmt.Sprintf("stack/file_%d.yaml", idx))
+	}
+
+	return names
+}
@@ -0,0 +1,140 @@
+package prhandler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+)
+
+const (
+	maxLinkedIssues   = 100
+	linkedIssuesQuery = `query($owner: String!, $repo: String!, $num: Int!, $first: Int!) { repository(owner: $owner, name: $repo) { pullRequest(number: $num) { closingIssuesReferences(first: $first) { nodes { number } } } } }` // nolint: lll
+)
+
+// reviewPullRequest reviews the metadata of the pull request, and returns the decision as the result of the pull
+// request route. A failure to gather the metadata is reported by the result as well.
+func reviewPullRequest(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	prReviewer reviewer.PullRequestReviewer,
+) review.Result {
+	metadata, metadataErr := getPullRequestMetadata(ctx, client, pr)
+	if metadataErr != nil {
+		return review.Result{Route: review.PullRequestRoute, Error: metadataErr}
+	}
+
+	decision, err := prReviewer.Review(ctx, metadata)
+	if err != nil {
+		return review.Result{
+			Route: review.PullRequestRoute,
+			Error: fmt.Errorf("failed to review pull request: %w", err),
+		}
+	}
+
+	return review.Result{Route: review.PullRequestRoute, Decision: decision}
+}
+
+// getPullRequestMetadata returns the metadata of the pull request given by the event, along with its commit messages
+// and the issues it closes, which are not part of the event.
+func getPullRequestMetadata(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+) (*reviewer.PullRequest, error) {
+	messages, commitsErr := listCommitMessages(ctx, client, pr)
+	if commitsErr != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", commitsErr)
+	}
+
+	issues, issuesErr := listLinkedIssues(ctx, client, pr)
+	if issuesErr != nil {
+		return nil, fmt.Errorf("failed to list linked issues: %w", issuesErr)
+	}
+
+	details := pr.details
+	if details == nil {
+		details = new(github.PullRequest)
+	}
+
+	labels := make([]string, 0, len(details.Labels))
+	for _, label := range details.Labels {
+		labels = append(labels, label.GetName())
+	}
+
+	return &reviewer.PullRequest{
+		Number:            details.GetNumber(),
+		Title:             details.GetTitle(),
+		Body:              details.GetBody(),
+		Labels:            labels,
+		BaseRef:           details.GetBase().GetRef(),
+		HeadRef:           details.GetHead().GetRef(),
+		Author:            details.GetUser().GetLogin(),
+		AuthorAssociation: details.GetAuthorAssociation(),
+		Draft:             details.GetDraft(),
+		LinkedIssues:      issues,
+		CommitMessages:    messages,
+	}, nil
+}
+
+// listCommitMessages returns the messages of the commits of the pull request, oldest first.
+func listCommitMessages(ctx context.Context, client *github.Client, pr *pullRequest) ([]string, error) {
+	opt := &github.ListOptions{
+		PerPage: numResultsPerPage,
+	}
+
+	messages := make([]string, 0)
+	for {
+		commits, resp, err := client.PullRequests.ListCommits(ctx, pr.getOwner(), pr.getRepoName(), pr.num, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commit := range commits {
+			messages = append(messages, commit.GetCommit().GetMessage())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.Page = resp.NextPage
+	}
+
+	return messages, nil
+}
+
+// listLinkedIssues returns the numbers of the issues the pull request closes once merged, which are only exposed by the
+// GraphQL API.
+func listLinkedIssues(ctx context.Context, client *github.Client, pr *pullRequest) ([]int, error) {
+	data := new(struct {
+		Repository struct {
+			PullRequest struct {
+				ClosingIssuesReferences struct {
+					Nodes []struct {
+						Number int `json:"number"`
+					} `json:"nodes"`
+				} `json:"closingIssuesReferences"`
+			} `json:"pullRequest"`
+		} `json:"repository"`
+	})
+
+	if err := queryGraphQL(ctx, client, linkedIssuesQuery, map[string]any{
+		"owner": pr.getOwner(),
+		"repo":  pr.getRepoName(),
+		"num":   pr.num,
+		"first": maxLinkedIssues,
+	}, data); err != nil {
+		return nil, err
+	}
+
+	issues := make([]int, 0)
+	for _, node := range data.Repository.PullRequest.ClosingIssuesReferences.Nodes {
+		issues = append(issues, node.Number)
+	}
+
+	return issues, nil
+}
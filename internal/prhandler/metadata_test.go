@@ -0,0 +1,126 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockErrPullRequestReviewer struct{}
+
+func (m *mockErrPullRequestReviewer) Review(_ context.Context, _ *reviewer.PullRequest) (*reviewer.Decision, error) {
+	return nil, errors.New("invalid")
+}
+
+func TestReviewPullRequest(t *testing.T) {
+	cases := map[string]struct {
+		listCommitsErr  bool
+		linkedIssuesErr bool
+		prReviewer      reviewer.PullRequestReviewer
+		expectedPR      *reviewer.PullRequest
+		expectedErrMsg  string
+	}{
+		"review pull request metadata": {
+			prReviewer: new(mockPullRequestReviewer),
+			expectedPR: &reviewer.PullRequest{
+				Number:            2,
+				Title:             "feat: add queue",
+				Body:              "Closes #3",
+				Labels:            []string{"infra"},
+				BaseRef:           "main",
+				HeadRef:           "feat/queue",
+				Author:            "octocat",
+				AuthorAssociation: "MEMBER",
+				Draft:             true,
+				LinkedIssues:      []int{3},
+				CommitMessages:    []string{"feat: add queue", "fixup! feat: add queue"},
+			},
+		},
+		"failed to list commits should return error result": {
+			listCommitsErr: true,
+			prReviewer:     new(mockPullRequestReviewer),
+			expectedErrMsg: "failed to list commits: ",
+		},
+		"failed to list linked issues should return error result": {
+			linkedIssuesErr: true,
+			prReviewer:      new(mockPullRequestReviewer),
+			expectedErrMsg:  "failed to list linked issues: resource not accessible",
+		},
+		"failed to review pull request should return error result": {
+			prReviewer:     new(mockErrPullRequestReviewer),
+			expectedErrMsg: "failed to review pull request: invalid",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+
+			listCommitsMock := mock.WithRequestMatch(
+				mock.GetReposPullsCommitsByOwnerByRepoByPullNumber,
+				[]*github.RepositoryCommit{
+					{Commit: &github.Commit{Message: github.String("feat: add queue")}},
+					{Commit: &github.Commit{Message: github.String("fixup! feat: add queue")}},
+				},
+			)
+
+			if tc.listCommitsErr {
+				listCommitsMock = mock.WithRequestMatchHandler(
+					mock.GetReposPullsCommitsByOwnerByRepoByPullNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						mock.WriteError(w, http.StatusBadRequest, "bad request")
+					}),
+				)
+			}
+
+			linkedIssues := `{"data":{"repository":{"pullRequest":{"closingIssuesReferences":{"nodes":[{"number":3}]}}}}}`
+			if tc.linkedIssuesErr {
+				linkedIssues = `{"data":null,"errors":[{"message":"resource not accessible"}]}`
+			}
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				listCommitsMock,
+				mock.WithRequestMatchHandler(
+					mock.EndpointPattern{Pattern: "/graphql", Method: http.MethodPost},
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						_, _ = w.Write([]byte(linkedIssues))
+					}),
+				),
+			))
+
+			pr := getPullRequest()
+			pr.details = &github.PullRequest{
+				Number:            github.Int(2),
+				Title:             github.String("feat: add queue"),
+				Body:              github.String("Closes #3"),
+				Labels:            []*github.Label{{Name: github.String("infra")}},
+				Base:              &github.PullRequestBranch{Ref: github.String("main")},
+				Head:              &github.PullRequestBranch{Ref: github.String("feat/queue")},
+				User:              &github.User{Login: github.String("octocat")},
+				AuthorAssociation: github.String("MEMBER"),
+				Draft:             github.Bool(true),
+			}
+
+			result := reviewPullRequest(context.TODO(), client, pr, tc.prReviewer)
+
+			a.Equal(review.PullRequestRoute, result.Route)
+			a.False(result.IsFile())
+			if tc.expectedErrMsg != "" {
+				a.Nil(result.Decision)
+				a.ErrorContains(result.Error, tc.expectedErrMsg)
+				return
+			}
+
+			a.Nil(result.Error)
+			a.False(result.Decision.Allow)
+			a.Equal(tc.expectedPR, tc.prReviewer.(*mockPullRequestReviewer).pr)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package prhandler
+
+import (
+	"context"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/google/go-github/v58/github"
+)
+
+// publisher publishes the outcome of a review to the outputs enabled by the repository configuration.
+type publisher struct {
+	client           *github.Client
+	pr               *pullRequest
+	cfg              *repoconfig.Config
+	minimizeComments bool
+	botLogin         func(ctx context.Context) (string, error)
+	checkRunID       int64
+}
+
+// start creates the in progress check run if check runs are enabled.
+func (p *publisher) start(ctx context.Context) error {
+	if !p.cfg.HasOutput(repoconfig.OutputCheckRun) {
+		return nil
+	}
+
+	id, err := createCheckRun(ctx, p.client, p.pr)
+	p.checkRunID = id
+	return err
+}
+
+// fail completes the check run as failed with the error which interrupted the review, and returns the error.
+func (p *publisher) fail(ctx context.Context, err error) error {
+	if p.checkRunID == 0 {
+		return err
+	}
+
+	return failCheckRun(ctx, p.client, p.pr, p.checkRunID, err)
+}
+
+// publish completes the check run with the output and posts its summary as the review comment.
+func (p *publisher) publish(ctx context.Context, outcome string, output *checkRunOutput) error {
+	if p.checkRunID != 0 {
+		if err := completeCheckRun(ctx, p.client, p.pr, p.checkRunID, output); err != nil {
+			return err
+		}
+	}
+
+	if !p.cfg.HasOutput(repoconfig.OutputComment) {
+		return nil
+	}
+
+	login, loginErr := p.botLogin(ctx)
+	if loginErr != nil {
+		return loginErr
+	}
+
+	return upsertComment(ctx, p.client, p.pr, login, runSummary(p.pr, outcome), output.summary, p.minimizeComments)
+}
+
+// publishResults publishes the review results, along with the inline review comments of the changed lines.
+func (p *publisher) publishResults(ctx context.Context, results []review.Result, patches map[string]string) error {
+	if err := p.publish(ctx, presentation.Outcome(results), newCheckRunOutput(results)); err != nil {
+		return err
+	}
+
+	if !p.cfg.HasOutput(repoconfig.OutputReview) {
+		return nil
+	}
+
+	login, loginErr := p.botLogin(ctx)
+	if loginErr != nil {
+		return loginErr
+	}
+
+	return createReview(ctx, p.client, p.pr, login, results, patches)
+}
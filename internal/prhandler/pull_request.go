@@ -1,8 +1,10 @@
 package prhandler
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
 	"github.com/google/go-github/v58/github"
 )
 
@@ -10,8 +12,53 @@ type pullRequest struct {
 	num            int
 	repo           *github.Repository
 	sha            string
+	baseSHA        string
 	action         string
+	defaultBranch  string
 	installationID int64
+	details        *github.PullRequest
+}
+
+// newPullRequest returns the pull request of the given details, in the repository of the installation, which received
+// the given action.
+func newPullRequest(
+	details *github.PullRequest,
+	repo *github.Repository,
+	installationID int64,
+	action string,
+) *pullRequest {
+	return &pullRequest{
+		num:            details.GetNumber(),
+		repo:           repo,
+		sha:            details.GetHead().GetSHA(),
+		baseSHA:        details.GetBase().GetSHA(),
+		action:         action,
+		defaultBranch:  repo.GetDefaultBranch(),
+		installationID: installationID,
+		details:        details,
+	}
+}
+
+// resolveMergeBase sets the base commit of the pull request to the merge base of its base and head commits, the commit
+// its changes are made against, since the tip of the base branch may have moved on since the pull request was opened.
+func (pr *pullRequest) resolveMergeBase(ctx context.Context, client *github.Client) error {
+	comparison, _, err := client.Repositories.CompareCommits(
+		ctx,
+		pr.getOwner(),
+		pr.getRepoName(),
+		pr.details.GetBase().GetSHA(),
+		pr.sha,
+		&github.ListOptions{PerPage: 1},
+	)
+	if err != nil {
+		return err
+	}
+
+	if sha := comparison.GetMergeBaseCommit().GetSHA(); sha != "" {
+		pr.baseSHA = sha
+	}
+
+	return nil
 }
 
 func (pr pullRequest) getPullRequestString() string {
@@ -25,3 +72,14 @@ func (pr pullRequest) getOwner() string {
 func (pr pullRequest) getRepoName() string {
 	return pr.repo.GetName()
 }
+
+// envelope returns the context of the files changed by the pull request.
+func (pr pullRequest) envelope() reviewer.Envelope {
+	return reviewer.Envelope{
+		Repository:  pr.repo.GetFullName(),
+		BaseRef:     pr.details.GetBase().GetRef(),
+		HeadSHA:     pr.sha,
+		PullRequest: pr.num,
+		Author:      pr.details.GetUser().GetLogin(),
+	}
+}
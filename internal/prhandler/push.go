@@ -0,0 +1,296 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/reader"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+)
+
+const (
+	pushEvent            = "push"
+	zeroSHA              = "0000000000000000000000000000000000000000"
+	statusUnchanged      = "unchanged"
+	statusStatePending   = "pending"
+	statusStateSuccess   = "success"
+	statusStateFailure   = "failure"
+	statusStateError     = "error"
+	maxStatusDescription = 140
+)
+
+// push is a push to a branch, whose after and before commits are the head and the base of the review. A scheduled push
+// is not made by anyone, it stands for the scheduled full review of the head of the branch.
+type push struct {
+	*pullRequest
+	ref       string
+	deleted   bool
+	scheduled bool
+	pushedAt  time.Time
+}
+
+// handlePush reviews the files changed by a push to the default branch, so the violations which bypassed the pull
+// requests, e.g. direct pushes or admin merges, are caught. All the files are reviewed instead by the first push of a
+// new branch, and by the first push of every full review interval of the repository configuration, the intervals
+// without any push being covered by the scheduled reviews. The outcome is recorded as a commit status, and the results
+// are reported by the tracking issue of the repository.
+func (h *handler) handlePush(ctx context.Context, payload []byte) error {
+	target, eventErr := parsePushEvent(payload)
+	if eventErr != nil {
+		return eventErr
+	}
+
+	ctx, logger := githubapp.PrepareRepoContext(ctx, target.installationID, target.repo)
+	if target.deleted || target.ref != branchRefPrefix+target.defaultBranch {
+		logger.Info().Msgf("received push to %s, no further processing is required", target.ref)
+		return nil
+	}
+
+	client, clientErr := h.clientCreator.NewInstallationClient(target.installationID)
+	if clientErr != nil {
+		return clientErr
+	}
+
+	logger.Debug().Msgf("loading %s of %s", repoconfig.Path, target.repo.GetFullName())
+	cfg, cfgErr := repoconfig.Load(ctx, client, target.getOwner(), target.getRepoName(), target.sha)
+
+	var validationErr *repoconfig.ValidationError
+	if errors.As(cfgErr, &validationErr) {
+		return recordStatus(ctx, client, target.pullRequest, statusStateFailure, "invalid configuration", "")
+	}
+
+	if cfgErr != nil {
+		return cfgErr
+	}
+
+	return h.reviewDefaultBranch(ctx, client, target, cfg)
+}
+
+// reviewDefaultBranch reviews the push to the default branch, records the outcome as a commit status of its head, and
+// reports the results by the tracking issue of the repository.
+func (h *handler) reviewDefaultBranch(
+	ctx context.Context,
+	client *github.Client,
+	target *push,
+	cfg *repoconfig.Config,
+) error {
+	logger := zerolog.Ctx(ctx)
+	if err := recordStatus(ctx, client, target.pullRequest, statusStatePending, "review in progress", ""); err != nil {
+		return err
+	}
+
+	issues, listErr := listTrackingIssues(ctx, client, target.getOwner(), target.getRepoName())
+	if listErr != nil {
+		return h.failPush(ctx, client, target, listErr)
+	}
+
+	full, fullErr := isFullReview(ctx, client, target, cfg, issues)
+	if fullErr != nil {
+		return h.failPush(ctx, client, target, fullErr)
+	}
+
+	logger.Debug().Msgf("reviewing push of %s, all files: %t", target.sha, full)
+	results, reviewErr := h.reviewPush(ctx, client, target, cfg, full)
+	if errors.Is(reviewErr, errTooManyFiles) {
+		// The changed files cannot all be listed, so all the files are reviewed instead.
+		logger.Debug().Msgf("reviewing all files of %s, as %s", target.sha, reviewErr)
+		full = true
+		results, reviewErr = h.reviewPush(ctx, client, target, cfg, full)
+	}
+
+	if reviewErr != nil {
+		return h.failPush(ctx, client, target, reviewErr)
+	}
+
+	// A full review matching no file still fixes the violations of the files which were removed or excluded.
+	if results == nil && !full {
+		return recordStatus(ctx, client, target.pullRequest, statusStateSuccess, noMatchedFilesMsg, "")
+	}
+
+	url, trackErr := trackViolations(ctx, client, target.pullRequest, cfg, issues, results, full)
+	if trackErr != nil {
+		return h.failPush(ctx, client, target, trackErr)
+	}
+
+	state, description := statusStateSuccess, noMatchedFilesMsg
+	if results != nil {
+		description = presentation.Title(results)
+	}
+
+	if presentation.Conclusion(results) == presentation.ConclusionFailure {
+		state = statusStateFailure
+	}
+
+	return recordStatus(ctx, client, target.pullRequest, state, description, url)
+}
+
+// reviewPush reviews all the files of the pushed commit if full is set, which are not reviewed as a changeset, or the
+// files changed by the push otherwise.
+// Results are nil if no file is matched.
+func (h *handler) reviewPush(
+	ctx context.Context,
+	client *github.Client,
+	target *push,
+	cfg *repoconfig.Config,
+	full bool,
+) ([]review.Result, error) {
+	if !full {
+		return h.reviewComparison(ctx, client, target.pullRequest, cfg)
+	}
+
+	files, filesErr := listTreeFiles(ctx, client, target.pullRequest)
+	if filesErr != nil {
+		return nil, filesErr
+	}
+
+	matchedFiles := getMatchingFiles(files, h.matcher, cfg)
+	if len(matchedFiles) == 0 {
+		return nil, nil
+	}
+
+	results, reviewErr := h.reviewSvc.Review(
+		ctx,
+		reader.ReadGitHubFile(client, target.getOwner(), target.getRepoName(), target.sha),
+		matchedFiles,
+		review.WithEnvelope(target.envelope()),
+		review.WithoutChangeset(),
+	)
+	if reviewErr != nil {
+		return nil, reviewErr
+	}
+
+	return cfg.Apply(results), nil
+}
+
+// failPush records the error which interrupted the review of the push as an errored commit status, and returns it.
+func (h *handler) failPush(ctx context.Context, client *github.Client, target *push, reviewErr error) error {
+	statusErr := recordStatus(ctx, client, target.pullRequest, statusStateError, "review failed", "")
+	return errors.Join(reviewErr, statusErr)
+}
+
+// isFullReview reports whether all the files of the push are reviewed, which is the case for the scheduled reviews, the
+// first push of a branch, when a tracking issue is truncated, and for the first push of a full review interval,
+// compared with the commit date of the previous head commit.
+func isFullReview(
+	ctx context.Context,
+	client *github.Client,
+	target *push,
+	cfg *repoconfig.Config,
+	issues []trackingIssue,
+) (bool, error) {
+	if target.scheduled || target.baseSHA == zeroSHA {
+		return true, nil
+	}
+
+	// The violations left out of a truncated tracking issue are only found again by reviewing all the files.
+	for idx := range issues {
+		if issues[idx].truncated {
+			return true, nil
+		}
+	}
+
+	if cfg.FullReviewInterval == "" {
+		return false, nil
+	}
+
+	previous, _, err := client.Git.GetCommit(ctx, target.getOwner(), target.getRepoName(), target.baseSHA)
+	if err != nil {
+		return false, err
+	}
+
+	return cfg.FullReviewDue(previous.GetCommitter().GetDate().Time, target.pushedAt), nil
+}
+
+// listTreeFiles lists the files of the head commit of the target as unchanged files.
+func listTreeFiles(ctx context.Context, client *github.Client, target *pullRequest) ([]review.ChangedFile, error) {
+	names, truncated, err := reader.ListGitHubFiles(ctx, client, target.getOwner(), target.getRepoName(), target.sha)
+	if err != nil {
+		return nil, err
+	}
+
+	if truncated {
+		zerolog.Ctx(ctx).Warn().Msgf("the tree of %s is truncated, only part of its files are reviewed", target.sha)
+	}
+
+	files := make([]review.ChangedFile, 0, len(names))
+	for _, name := range names {
+		files = append(files, review.ChangedFile{Name: name, Status: statusUnchanged})
+	}
+
+	return files, nil
+}
+
+// recordStatus records the outcome of the review as the commit status of the head commit of the target. The
+// description is truncated to the number of characters accepted by GitHub.
+func recordStatus(
+	ctx context.Context,
+	client *github.Client,
+	target *pullRequest,
+	state string,
+	description string,
+	url string,
+) error {
+	if runes := []rune(description); len(runes) > maxStatusDescription {
+		description = string(runes[:maxStatusDescription])
+	}
+
+	status := &github.RepoStatus{
+		State:       github.String(state),
+		Description: github.String(description),
+		Context:     github.String(checkRunName),
+	}
+
+	if url != "" {
+		status.TargetURL = github.String(url)
+	}
+
+	_, _, err := client.Repositories.CreateStatus(ctx, target.getOwner(), target.getRepoName(), target.sha, status)
+	return err
+}
+
+// parsePushEvent parses a push event. The push holds a pull request without number, whose head and base are the after
+// and before commits of the push.
+func parsePushEvent(payload []byte) (*push, error) {
+	event, err := github.ParseWebHook(pushEvent, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	pushed, ok := event.(*github.PushEvent)
+	if !ok {
+		return nil, fmt.Errorf("unexpected event payload type %s found", pushEvent)
+	}
+
+	eventRepo := pushed.GetRepo()
+	repo := &github.Repository{
+		ID:            eventRepo.ID,
+		Name:          eventRepo.Name,
+		FullName:      eventRepo.FullName,
+		Owner:         eventRepo.Owner,
+		HTMLURL:       eventRepo.HTMLURL,
+		DefaultBranch: eventRepo.DefaultBranch,
+	}
+
+	return &push{
+		pullRequest: &pullRequest{
+			repo:           repo,
+			sha:            pushed.GetAfter(),
+			baseSHA:        pushed.GetBefore(),
+			defaultBranch:  repo.GetDefaultBranch(),
+			installationID: pushed.GetInstallation().GetID(),
+			details: &github.PullRequest{
+				Base: &github.PullRequestBranch{Ref: repo.DefaultBranch},
+			},
+		},
+		ref:      pushed.GetRef(),
+		deleted:  pushed.GetDeleted(),
+		pushedAt: pushed.GetHeadCommit().GetTimestamp().Time,
+	}, nil
+}
@@ -0,0 +1,298 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Handle_Push(t *testing.T) {
+	closedIssue := "<!-- opa-reviewer-tracking -->\n<!-- opa-reviewer-violations: [] -->\nAll the violations of `main` are fixed.\n" // nolint: lll
+	cases := map[string]struct {
+		payload            []byte
+		config             string
+		changedFiles       []string
+		treeFiles          []string
+		previousCommitAt   time.Time
+		trackingIssue      *github.Issue
+		expectedStates     []string
+		expectedURL        string
+		expectedIssue      string
+		expectedIssueState string
+	}{
+		"record failed review of changed files": {
+			payload:        getPushPayload("refs/heads/main", "67890", false),
+			changedFiles:   []string{"stack/invalid.yaml", "docs/README.md"},
+			expectedStates: []string{"pending", "failure"},
+		},
+		"keep violations of unchanged files": {
+			payload:        getPushPayload("refs/heads/main", "67890", false),
+			changedFiles:   []string{"stack/file_1.yaml"},
+			trackingIssue:  getTrackingIssue("stack/other.yaml"),
+			expectedStates: []string{"pending", "success"},
+			expectedURL:    "https://github.com/owner/repo/issues/3",
+		},
+		"close tracking issue once violations are fixed": {
+			payload:            getPushPayload("refs/heads/main", "67890", false),
+			changedFiles:       []string{"stack/file_1.yaml"},
+			trackingIssue:      getTrackingIssue("stack/file_1.yaml"),
+			expectedStates:     []string{"pending", "success"},
+			expectedIssue:      closedIssue,
+			expectedIssueState: "closed",
+		},
+		"review all files of new branch": {
+			payload:            getPushPayload("refs/heads/main", zeroSHA, false),
+			treeFiles:          []string{"stack/file_1.yaml"},
+			trackingIssue:      getTrackingIssue("stack/other.yaml"),
+			expectedStates:     []string{"pending", "success"},
+			expectedIssue:      closedIssue,
+			expectedIssueState: "closed",
+		},
+		"review all files of first push of interval": {
+			payload:            getPushPayload("refs/heads/main", "67890", false),
+			config:             "full_review_interval: 24h",
+			treeFiles:          []string{"stack/file_1.yaml"},
+			previousCommitAt:   time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC),
+			trackingIssue:      getTrackingIssue("stack/other.yaml"),
+			expectedStates:     []string{"pending", "success"},
+			expectedIssue:      closedIssue,
+			expectedIssueState: "closed",
+		},
+		"review all files when changed files cannot all be listed": {
+			payload:            getPushPayload("refs/heads/main", "67890", false),
+			changedFiles:       getFileNames(maxComparisonFiles),
+			treeFiles:          []string{"stack/file_1.yaml"},
+			trackingIssue:      getTrackingIssue("stack/other.yaml"),
+			expectedStates:     []string{"pending", "success"},
+			expectedIssue:      closedIssue,
+			expectedIssueState: "closed",
+		},
+		"review all files when tracking issue is truncated": {
+			payload:            getPushPayload("refs/heads/main", "67890", false),
+			treeFiles:          []string{"stack/file_1.yaml"},
+			trackingIssue:      getTruncatedTrackingIssue("stack/other.yaml"),
+			expectedStates:     []string{"pending", "success"},
+			expectedIssue:      closedIssue,
+			expectedIssueState: "closed",
+		},
+		"review changed files within interval": {
+			payload:          getPushPayload("refs/heads/main", "67890", false),
+			config:           "full_review_interval: 24h",
+			changedFiles:     []string{"stack/file_1.yaml"},
+			previousCommitAt: time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC),
+			trackingIssue:    getTrackingIssue("stack/other.yaml"),
+			expectedStates:   []string{"pending", "success"},
+			expectedURL:      "https://github.com/owner/repo/issues/3",
+		},
+		"record no matched files": {
+			payload:        getPushPayload("refs/heads/main", "67890", false),
+			changedFiles:   []string{"docs/README.md"},
+			expectedStates: []string{"pending", "success"},
+		},
+		"record invalid configuration": {
+			payload:        getPushPayload("refs/heads/main", "67890", false),
+			config:         "outputs: [email]",
+			expectedStates: []string{"failure"},
+		},
+		"ignore push to other branch": {
+			payload: getPushPayload("refs/heads/feature", "67890", false),
+		},
+		"ignore deleted default branch": {
+			payload: getPushPayload("refs/heads/main", "67890", true),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var states []string
+			var url, issue, issueState string
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposContentsByOwnerByRepoByPath,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						if tc.config == "" {
+							mock.WriteError(w, http.StatusNotFound, "not found")
+							return
+						}
+
+						_, _ = w.Write(mock.MustMarshal(github.RepositoryContent{Content: github.String(tc.config)}))
+					}),
+				),
+				mock.WithRequestMatch(
+					mock.GetReposCompareByOwnerByRepoByBasehead,
+					github.CommitsComparison{Files: toCommitFiles(tc.changedFiles, "modified")},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposGitTreesByOwnerByRepoByTreeSha,
+					github.Tree{Entries: toTreeEntries(tc.treeFiles)},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposGitCommitsByOwnerByRepoByCommitSha,
+					github.Commit{Committer: &github.CommitAuthor{Date: &github.Timestamp{Time: tc.previousCommitAt}}},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposIssuesByOwnerByRepo,
+					toIssues(tc.trackingIssue),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PatchReposIssuesByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						edited := decodeIssue(req)
+						issue, issueState = edited.GetBody(), edited.GetState()
+						_, _ = w.Write(mock.MustMarshal(github.Issue{
+							HTMLURL: github.String("https://github.com/owner/repo/issues/3"),
+						}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PostReposStatusesByOwnerByRepoBySha,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						status := new(github.RepoStatus)
+						_ = json.NewDecoder(req.Body).Decode(status)
+						states = append(states, status.GetState())
+						url = status.GetTargetURL()
+						_, _ = w.Write(mock.MustMarshal(status))
+					}),
+				),
+			))
+
+			h := New(
+				&mockClientCreator{client: client},
+				filematch.New([]string{"stack/**/*.yaml"}),
+				new(mockReviewSvc),
+			)
+
+			err := h.Handle(context.TODO(), pushEvent, "", tc.payload)
+
+			a.Nil(err)
+			a.Equal(tc.expectedStates, states)
+			a.Equal(tc.expectedURL, url)
+			a.Equal(tc.expectedIssue, issue)
+			a.Equal(tc.expectedIssueState, issueState)
+		})
+	}
+}
+
+func TestRecordStatus(t *testing.T) {
+	a := assert.New(t)
+	var description string
+
+	client := github.NewClient(mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.PostReposStatusesByOwnerByRepoBySha,
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				defer req.Body.Close()
+				status := new(github.RepoStatus)
+				_ = json.NewDecoder(req.Body).Decode(status)
+				description = status.GetDescription()
+				_, _ = w.Write(mock.MustMarshal(status))
+			}),
+		),
+	))
+
+	target := &pullRequest{
+		repo: &github.Repository{
+			Name:  github.String("repo"),
+			Owner: &github.User{Login: github.String("owner")},
+		},
+		sha: "abcdef",
+	}
+	err := recordStatus(context.TODO(), client, target, statusStateSuccess, strings.Repeat("a", 200), "")
+
+	a.Nil(err)
+	a.Len(description, maxStatusDescription)
+
+	err = recordStatus(context.TODO(), client, target, statusStateSuccess, strings.Repeat("é", 200), "")
+
+	a.Nil(err)
+	a.Equal(strings.Repeat("é", maxStatusDescription), description)
+}
+
+func toTreeEntries(files []string) []*github.TreeEntry {
+	entries := []*github.TreeEntry{{Path: github.String("stack"), Type: github.String("tree")}}
+	for _, file := range files {
+		entries = append(entries, &github.TreeEntry{Path: github.String(file), Type: github.String("blob")})
+	}
+
+	return entries
+}
+
+func getTrackingIssue(file string) *github.Issue {
+	body, _ := renderTrackingIssue("", "main", []presentation.FileViolation{{
+		File: file,
+		Violation: &reviewer.Violation{
+			RuleID:   "rule_1",
+			Severity: reviewer.SeverityError,
+			Message:  "violation_1",
+			Package:  "reviewer.cfn",
+		},
+	}})
+
+	return &github.Issue{
+		Number:  github.Int(3),
+		Body:    github.String(body),
+		HTMLURL: github.String("https://github.com/owner/repo/issues/3"),
+	}
+}
+
+func getTruncatedTrackingIssue(file string) *github.Issue {
+	issue := getTrackingIssue(file)
+	lines := strings.SplitN(issue.GetBody(), "\n", 3)
+	issue.Body = github.String(strings.Join([]string{lines[0], lines[1], trackingTruncatedMarker, lines[2]}, "\n"))
+	return issue
+}
+
+func toIssues(issue *github.Issue) []*github.Issue {
+	if issue == nil {
+		return make([]*github.Issue, 0)
+	}
+
+	return []*github.Issue{issue}
+}
+
+func decodeIssue(req *http.Request) *github.IssueRequest {
+	defer req.Body.Close()
+	issue := new(github.IssueRequest)
+	_ = json.NewDecoder(req.Body).Decode(issue)
+	return issue
+}
+
+func getPushPayload(ref, before string, deleted bool) []byte {
+	payload := map[string]any{
+		"ref":     ref,
+		"before":  before,
+		"after":   "abcdef",
+		"deleted": deleted,
+		"head_commit": map[string]any{
+			"id":        "abcdef",
+			"timestamp": "2024-01-02T09:00:00Z",
+		},
+		"repository": map[string]any{
+			"name":           "repo",
+			"full_name":      "owner/repo",
+			"default_branch": "main",
+			"owner": map[string]any{
+				"login": "owner",
+			},
+		},
+		"installation": map[string]any{
+			"id": 12345678,
+		},
+	}
+
+	bs, _ := json.Marshal(payload)
+	return bs
+}
@@ -0,0 +1,128 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+	"github.com/rs/zerolog"
+)
+
+const (
+	checkRunEvent     = "check_run"
+	checkSuiteEvent   = "check_suite"
+	rerequestedAction = "rerequested"
+	pullRequestOpen   = "open"
+)
+
+// rerunEvent is a check run or a check suite whose re-run was requested, e.g. with the Re-run button of the checks.
+type rerunEvent struct {
+	action         string
+	checkRunName   string
+	headSHA        string
+	pullRequests   []*github.PullRequest
+	repo           *github.Repository
+	installationID int64
+}
+
+// handleRerun reviews again the open pull requests of the head commit of a rerequested check run or check suite. Only
+// the check runs of the reviewer are re-run, and the pull requests from forks are not part of the event.
+func (h *handler) handleRerun(ctx context.Context, eventType string, payload []byte) error {
+	event, eventErr := parseRerunEvent(eventType, payload)
+	if eventErr != nil {
+		return eventErr
+	}
+
+	logger := zerolog.Ctx(ctx)
+	if event.action != rerequestedAction || (eventType == checkRunEvent && event.checkRunName != checkRunName) {
+		logger.Info().Msgf("received %s action %s, no further processing is required", eventType, event.action)
+		return nil
+	}
+
+	if len(event.pullRequests) == 0 {
+		logger.Info().Msgf("no pull request found for %s of %s", eventType, event.headSHA)
+		return nil
+	}
+
+	client, clientErr := h.clientCreator.NewInstallationClient(event.installationID)
+	if clientErr != nil {
+		return clientErr
+	}
+
+	var errs error
+	for _, ref := range event.pullRequests {
+		errs = errors.Join(errs, h.rerun(ctx, client, event, ref.GetNumber()))
+	}
+
+	return errs
+}
+
+// rerun reviews the pull request of the given number again on the head commit of the event, unless it is closed or
+// the head commit of the event is no longer its head.
+func (h *handler) rerun(ctx context.Context, client *github.Client, event *rerunEvent, num int) error {
+	ctx, logger := githubapp.PreparePRContext(ctx, event.installationID, event.repo, num)
+
+	details, _, prErr := client.PullRequests.Get(ctx, event.repo.GetOwner().GetLogin(), event.repo.GetName(), num)
+	if prErr != nil {
+		return prErr
+	}
+
+	pr := newPullRequest(details, event.repo, event.installationID, event.action)
+	if details.GetState() != pullRequestOpen {
+		logger.Info().Msgf("skipping re-run of %s which is %s", pr.getPullRequestString(), details.GetState())
+		return nil
+	}
+
+	// The changed files and the diff of the pull request are the ones of its head, so a re-run of an outdated commit is
+	// skipped, the head being reviewed by its own check run.
+	if pr.sha != event.headSHA {
+		logger.Info().Msgf("skipping re-run of %s on %s, which is not its head", pr.getPullRequestString(), event.headSHA)
+		return nil
+	}
+
+	logger.Info().Msgf("re-running review of %s on %s", pr.getPullRequestString(), pr.sha)
+	return h.withConfig(ctx, client, pr, func(cfg *repoconfig.Config) error {
+		return h.review(ctx, client, pr, cfg)
+	})
+}
+
+// parseRerunEvent parses a check run or check suite event.
+func parseRerunEvent(eventType string, payload []byte) (*rerunEvent, error) {
+	event, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e := event.(type) {
+	case *github.CheckRunEvent:
+		if e.CheckRun == nil {
+			return nil, errors.New("check run event without check run")
+		}
+
+		return &rerunEvent{
+			action:         e.GetAction(),
+			checkRunName:   e.CheckRun.GetName(),
+			headSHA:        e.CheckRun.GetHeadSHA(),
+			pullRequests:   e.CheckRun.PullRequests,
+			repo:           e.GetRepo(),
+			installationID: e.GetInstallation().GetID(),
+		}, nil
+	case *github.CheckSuiteEvent:
+		if e.CheckSuite == nil {
+			return nil, errors.New("check suite event without check suite")
+		}
+
+		return &rerunEvent{
+			action:         e.GetAction(),
+			headSHA:        e.CheckSuite.GetHeadSHA(),
+			pullRequests:   e.CheckSuite.PullRequests,
+			repo:           e.GetRepo(),
+			installationID: e.GetInstallation().GetID(),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unexpected event payload type %s found", eventType)
+	}
+}
@@ -0,0 +1,178 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_Handle_Rerun(t *testing.T) {
+	cases := map[string]struct {
+		eventType        string
+		payload          []byte
+		state            string
+		headSHA          string
+		expectedHeadSHAs []string
+		expectedErrMsg   string
+	}{
+		"rerun review of rerequested check run": {
+			eventType:        checkRunEvent,
+			payload:          getRerunPayload("check_run", "rerequested", checkRunName, 2),
+			state:            "open",
+			headSHA:          "abcdef",
+			expectedHeadSHAs: []string{"abcdef"},
+		},
+		"rerun review of every pull request of rerequested check suite": {
+			eventType:        checkSuiteEvent,
+			payload:          getRerunPayload("check_suite", "rerequested", "", 2, 3),
+			state:            "open",
+			headSHA:          "abcdef",
+			expectedHeadSHAs: []string{"abcdef", "abcdef"},
+		},
+		"ignore check run of other app": {
+			eventType: checkRunEvent,
+			payload:   getRerunPayload("check_run", "rerequested", "build", 2),
+			state:     "open",
+		},
+		"ignore completed check suite": {
+			eventType: checkSuiteEvent,
+			payload:   getRerunPayload("check_suite", "completed", "", 2),
+			state:     "open",
+		},
+		"ignore check suite without pull request": {
+			eventType: checkSuiteEvent,
+			payload:   getRerunPayload("check_suite", "rerequested", ""),
+			state:     "open",
+		},
+		"skip outdated commit of pull request": {
+			eventType: checkRunEvent,
+			payload:   getRerunPayload("check_run", "rerequested", checkRunName, 2),
+			state:     "open",
+			headSHA:   "12345",
+		},
+		"skip closed pull request": {
+			eventType: checkRunEvent,
+			payload:   getRerunPayload("check_run", "rerequested", checkRunName, 2),
+			state:     "closed",
+		},
+		"check run event without check run should return error": {
+			eventType:      checkRunEvent,
+			payload:        []byte(`{"action": "rerequested"}`),
+			expectedErrMsg: "check run event without check run",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			headSHAs := make([]string, 0)
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposPullsByOwnerByRepoByPullNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						_, _ = w.Write(mock.MustMarshal(github.PullRequest{
+							Number: github.Int(2),
+							State:  github.String(tc.state),
+							Head:   &github.PullRequestBranch{SHA: github.String(tc.headSHA)},
+							Base:   &github.PullRequestBranch{SHA: github.String("67890")},
+						}))
+					}),
+				),
+				mock.WithRequestMatch(
+					mock.GetReposCompareByOwnerByRepoByBasehead,
+					github.CommitsComparison{MergeBaseCommit: &github.RepositoryCommit{SHA: github.String("abcde")}},
+					github.CommitsComparison{MergeBaseCommit: &github.RepositoryCommit{SHA: github.String("abcde")}},
+				),
+				mock.WithRequestMatchHandler(
+					mock.GetReposContentsByOwnerByRepoByPath,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						mock.WriteError(w, http.StatusNotFound, "not found")
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PostReposCheckRunsByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						opts := new(github.CreateCheckRunOptions)
+						_ = json.NewDecoder(req.Body).Decode(opts)
+						headSHAs = append(headSHAs, opts.HeadSHA)
+						_, _ = w.Write(mock.MustMarshal(github.CheckRun{ID: github.Int64(1)}))
+					}),
+				),
+				mock.WithRequestMatch(
+					mock.GetReposPullsFilesByOwnerByRepoByPullNumber,
+					toCommitFiles([]string{"stack/file_1.yaml"}, "modified"),
+					toCommitFiles([]string{"stack/file_1.yaml"}, "modified"),
+				),
+				mock.WithRequestMatch(
+					mock.PatchReposCheckRunsByOwnerByRepoByCheckRunId,
+					github.CheckRun{ID: github.Int64(1)},
+					github.CheckRun{ID: github.Int64(1)},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					make([]*github.IssueComment, 0),
+					make([]*github.IssueComment, 0),
+				),
+				mock.WithRequestMatch(mock.GetApp, github.App{Slug: github.String("opa-reviewer")}),
+				mock.WithRequestMatch(
+					mock.PostReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					github.IssueComment{},
+					github.IssueComment{},
+				),
+			))
+
+			h := New(
+				&mockClientCreator{client: client},
+				filematch.New([]string{"stack/**/*.yaml"}),
+				new(mockReviewSvc),
+			)
+
+			err := h.Handle(context.TODO(), tc.eventType, "", tc.payload)
+			if tc.expectedErrMsg != "" {
+				a.ErrorContains(err, tc.expectedErrMsg)
+				return
+			}
+
+			a.Nil(err)
+			a.ElementsMatch(tc.expectedHeadSHAs, headSHAs)
+		})
+	}
+}
+
+func getRerunPayload(object, action, name string, nums ...int) []byte {
+	pullRequests := make([]map[string]any, 0, len(nums))
+	for _, num := range nums {
+		pullRequests = append(pullRequests, map[string]any{"number": num})
+	}
+
+	payload := map[string]any{
+		"action": action,
+		object: map[string]any{
+			"name":          name,
+			"head_sha":      "abcdef",
+			"pull_requests": pullRequests,
+		},
+		"repository": map[string]any{
+			"name":           "repo",
+			"full_name":      "owner/repo",
+			"default_branch": "main",
+			"owner": map[string]any{
+				"login": "owner",
+			},
+		},
+		"installation": map[string]any{
+			"id": 12345678,
+		},
+	}
+
+	bs, _ := json.Marshal(payload)
+	return bs
+}
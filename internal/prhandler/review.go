@@ -0,0 +1,169 @@
+package prhandler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+)
+
+const (
+	reviewEventComment = "COMMENT"
+	reviewSideRight    = "RIGHT"
+)
+
+// createReview posts the violations as a pull request review, with a comment attached to the offending line of every
+// violation found on a changed line. Violations on unchanged lines are listed in the body of the review instead.
+// No review is posted if none of the violations is on a changed line, as they are all reported by the summary, which
+// also reports the violations of the changeset and of the pull request. The comments the bot with the given login
+// already posted on the same line are not posted again by the following reviews.
+func createReview(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	login string,
+	results []review.Result,
+	patches map[string]string,
+) error {
+	comments := make([]*github.DraftReviewComment, 0)
+	fallbacks := make([]presentation.FileViolation, 0)
+
+	for idx := range results {
+		if results[idx].Decision == nil || !results[idx].IsFile() {
+			continue
+		}
+
+		file := results[idx].File
+		lines := commentableLines(patches[file])
+		for vIdx := range results[idx].Decision.Violations {
+			violation := &results[idx].Decision.Violations[vIdx]
+			comment, ok := draftComment(file, violation, lines)
+			if !ok {
+				fallbacks = append(fallbacks, presentation.FileViolation{File: file, Violation: violation})
+				continue
+			}
+
+			comments = append(comments, comment)
+		}
+	}
+
+	if len(comments) == 0 {
+		return nil
+	}
+
+	posted, listErr := listPostedComments(ctx, client, pr, login)
+	if listErr != nil {
+		return listErr
+	}
+
+	comments = slices.DeleteFunc(comments, func(comment *github.DraftReviewComment) bool {
+		return posted[reviewCommentKey(comment.GetPath(), comment.GetLine(), comment.GetBody())]
+	})
+
+	if len(comments) == 0 {
+		return nil
+	}
+
+	req := &github.PullRequestReviewRequest{
+		CommitID: github.String(pr.sha),
+		Event:    github.String(reviewEventComment),
+		Comments: comments,
+	}
+
+	if body := presentation.ReviewBody(fallbacks); body != "" {
+		req.Body = github.String(body)
+	}
+
+	_, _, err := client.PullRequests.CreateReview(ctx, pr.getOwner(), pr.getRepoName(), pr.num, req)
+	return err
+}
+
+// listPostedComments returns the keys of the review comments the bot with the given login posted on the pull request.
+func listPostedComments(
+	ctx context.Context,
+	client *github.Client,
+	pr *pullRequest,
+	login string,
+) (map[string]bool, error) {
+	opt := &github.PullRequestListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: numResultsPerPage},
+	}
+
+	posted := make(map[string]bool)
+	for {
+		comments, resp, err := client.PullRequests.ListComments(ctx, pr.getOwner(), pr.getRepoName(), pr.num, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, comment := range comments {
+			if isBotComment(comment.GetUser(), login) {
+				posted[reviewCommentKey(comment.GetPath(), comment.GetLine(), comment.GetBody())] = true
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return posted, nil
+		}
+
+		opt.Page = resp.NextPage
+	}
+}
+
+// reviewCommentKey identifies a review comment by its file, line and body.
+func reviewCommentKey(path string, line int, body string) string {
+	return fmt.Sprintf("%s:%d:%s", path, line, body)
+}
+
+// draftComment builds the review comment of a violation on a changed line. The suggestion of the violation is
+// attached when all the lines it replaces are changed lines, in which case the comment spans those lines.
+func draftComment(file string, violation *reviewer.Violation, lines map[int]bool) (*github.DraftReviewComment, bool) {
+	comment := &github.DraftReviewComment{
+		Path: github.String(file),
+		Side: github.String(reviewSideRight),
+		Body: github.String(presentation.InlineComment(violation)),
+	}
+
+	if suggestion := violation.Suggestion; suggestion != nil && allLines(lines, suggestion.StartLine, suggestion.EndLine) {
+		comment.Line = github.Int(suggestion.EndLine)
+		comment.Body = github.String(comment.GetBody() + "\n\n" + presentation.SuggestionBlock(suggestion))
+		if suggestion.StartLine != suggestion.EndLine {
+			comment.StartLine = github.Int(suggestion.StartLine)
+			comment.StartSide = github.String(reviewSideRight)
+		}
+
+		return comment, true
+	}
+
+	if !lines[violation.Location.StartLine] {
+		return nil, false
+	}
+
+	comment.Line = github.Int(violation.Location.StartLine)
+	return comment, true
+}
+
+// allLines reports whether every line between start and end is in lines.
+func allLines(lines map[int]bool, start, end int) bool {
+	for line := start; line <= end; line++ {
+		if !lines[line] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getPatches returns the patch of every changed file by its name.
+func getPatches(files []*github.CommitFile) map[string]string {
+	patches := make(map[string]string, len(files))
+	for idx := range files {
+		patches[files[idx].GetFilename()] = files[idx].GetPatch()
+	}
+
+	return patches
+}
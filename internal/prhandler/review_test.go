@@ -0,0 +1,212 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateReview(t *testing.T) {
+	violation := func(rule string, line int) reviewer.Violation {
+		return reviewer.Violation{
+			RuleID:   rule,
+			Severity: reviewer.SeverityError,
+			Message:  "violation",
+			Location: reviewer.Location{StartLine: line, EndLine: line},
+		}
+	}
+
+	cases := map[string]struct {
+		results  []review.Result
+		patches  map[string]string
+		posted   []*github.PullRequestComment
+		expected *github.PullRequestReviewRequest
+	}{
+		"comment on changed lines and fall back to the body": {
+			results: []review.Result{
+				{
+					File: "stack/file_1.yaml",
+					Decision: &reviewer.Decision{
+						Violations: []reviewer.Violation{violation("rule_1", 2), violation("rule_2", 5)},
+					},
+				},
+				{File: "stack/file_2.yaml", Decision: &reviewer.Decision{Allow: true}},
+				{File: "stack/file_3.yaml"},
+			},
+			patches: map[string]string{"stack/file_1.yaml": "@@ -1,2 +1,3 @@\n a: 1\n+b: 2\n c: 3"},
+			expected: &github.PullRequestReviewRequest{
+				CommitID: github.String("12345"),
+				Event:    github.String("COMMENT"),
+				Body:     github.String("Violations outside of the changed lines:\n\n* stack/file_1.yaml: [error] rule_2: violation (line 5)\n"), // nolint: lll
+				Comments: []*github.DraftReviewComment{
+					{
+						Path: github.String("stack/file_1.yaml"),
+						Line: github.Int(2),
+						Side: github.String("RIGHT"),
+						Body: github.String("**[error] rule_1**: violation"),
+					},
+				},
+			},
+		},
+		"attach suggestions on changed lines": {
+			results: []review.Result{
+				{
+					File: "stack/file_1.yaml",
+					Decision: &reviewer.Decision{
+						Violations: []reviewer.Violation{
+							withSuggestion(violation("rule_1", 2), &reviewer.Suggestion{StartLine: 2, EndLine: 3, Content: "b: 3"}),
+							withSuggestion(violation("rule_2", 2), &reviewer.Suggestion{StartLine: 1, EndLine: 1, Content: "a: 2"}),
+						},
+					},
+				},
+			},
+			patches: map[string]string{"stack/file_1.yaml": "@@ -1,2 +1,3 @@\n a: 1\n+b: 2\n c: 3"},
+			expected: &github.PullRequestReviewRequest{
+				CommitID: github.String("12345"),
+				Event:    github.String("COMMENT"),
+				Comments: []*github.DraftReviewComment{
+					{
+						Path:      github.String("stack/file_1.yaml"),
+						StartLine: github.Int(2),
+						StartSide: github.String("RIGHT"),
+						Line:      github.Int(3),
+						Side:      github.String("RIGHT"),
+						Body:      github.String("**[error] rule_1**: violation\n\n```suggestion\nb: 3\n```"),
+					},
+					{
+						Path: github.String("stack/file_1.yaml"),
+						Line: github.Int(1),
+						Side: github.String("RIGHT"),
+						Body: github.String("**[error] rule_2**: violation\n\n```suggestion\na: 2\n```"),
+					},
+				},
+			},
+		},
+		"skip comments already posted by the bot": {
+			results: []review.Result{
+				{
+					File: "stack/file_1.yaml",
+					Decision: &reviewer.Decision{
+						Violations: []reviewer.Violation{violation("rule_1", 2), violation("rule_2", 2)},
+					},
+				},
+			},
+			patches: map[string]string{"stack/file_1.yaml": "@@ -1,2 +1,3 @@\n a: 1\n+b: 2\n c: 3"},
+			posted: []*github.PullRequestComment{
+				{
+					Path: github.String("stack/file_1.yaml"),
+					Line: github.Int(2),
+					Body: github.String("**[error] rule_1**: violation"),
+					User: getBot(),
+				},
+				{
+					Path: github.String("stack/file_1.yaml"),
+					Line: github.Int(2),
+					Body: github.String("**[error] rule_2**: violation"),
+					User: &github.User{Login: github.String("author"), Type: github.String("User")},
+				},
+			},
+			expected: &github.PullRequestReviewRequest{
+				CommitID: github.String("12345"),
+				Event:    github.String("COMMENT"),
+				Comments: []*github.DraftReviewComment{
+					{
+						Path: github.String("stack/file_1.yaml"),
+						Line: github.Int(2),
+						Side: github.String("RIGHT"),
+						Body: github.String("**[error] rule_2**: violation"),
+					},
+				},
+			},
+		},
+		"skip review if all comments are already posted": {
+			results: []review.Result{
+				{
+					File:     "stack/file_1.yaml",
+					Decision: &reviewer.Decision{Violations: []reviewer.Violation{violation("rule_1", 2)}},
+				},
+			},
+			patches: map[string]string{"stack/file_1.yaml": "@@ -1,2 +1,3 @@\n a: 1\n+b: 2\n c: 3"},
+			posted: []*github.PullRequestComment{
+				{
+					Path: github.String("stack/file_1.yaml"),
+					Line: github.Int(2),
+					Body: github.String("**[error] rule_1**: violation"),
+					User: getBot(),
+				},
+			},
+		},
+		"skip review if no violation is on a changed line": {
+			results: []review.Result{
+				{
+					File:     "stack/file_1.yaml",
+					Decision: &reviewer.Decision{Violations: []reviewer.Violation{violation("rule_1", 5)}},
+				},
+			},
+			patches: map[string]string{"stack/file_1.yaml": "@@ -1,2 +1,3 @@\n a: 1\n+b: 2\n c: 3"},
+		},
+		"skip suggestion on unchanged lines": {
+			results: []review.Result{
+				{
+					File: "stack/file_1.yaml",
+					Decision: &reviewer.Decision{
+						Violations: []reviewer.Violation{
+							withSuggestion(violation("rule_1", 2), &reviewer.Suggestion{StartLine: 2, EndLine: 4, Content: ""}),
+						},
+					},
+				},
+			},
+			patches: map[string]string{"stack/file_1.yaml": "@@ -1,2 +1,3 @@\n a: 1\n+b: 2\n c: 3"},
+			expected: &github.PullRequestReviewRequest{
+				CommitID: github.String("12345"),
+				Event:    github.String("COMMENT"),
+				Comments: []*github.DraftReviewComment{
+					{
+						Path: github.String("stack/file_1.yaml"),
+						Line: github.Int(2),
+						Side: github.String("RIGHT"),
+						Body: github.String("**[error] rule_1**: violation"),
+					},
+				},
+			},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var req *github.PullRequestReviewRequest
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(
+					mock.GetReposPullsCommentsByOwnerByRepoByPullNumber,
+					append(make([]*github.PullRequestComment, 0), tc.posted...),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PostReposPullsReviewsByOwnerByRepoByPullNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						defer r.Body.Close()
+						req = new(github.PullRequestReviewRequest)
+						_ = json.NewDecoder(r.Body).Decode(req)
+						_, _ = w.Write(mock.MustMarshal(github.PullRequestReview{ID: github.Int64(1)}))
+					}),
+				),
+			))
+
+			a.Nil(createReview(context.TODO(), client, getPullRequest(), botLogin, tc.results, tc.patches))
+			a.Equal(tc.expected, req)
+		})
+	}
+}
+
+func withSuggestion(violation reviewer.Violation, suggestion *reviewer.Suggestion) reviewer.Violation {
+	violation.Suggestion = suggestion
+	return violation
+}
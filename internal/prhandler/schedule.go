@@ -0,0 +1,161 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/CameronXie/go-opa-reviewer/internal/reader"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/google/go-github/v58/github"
+	"github.com/palantir/go-githubapp/githubapp"
+)
+
+// BranchReviewer reviews all the files of the default branches of the repositories of an installation on schedule, so
+// the branches which are not pushed to are reviewed every full review interval as well.
+type BranchReviewer interface {
+	ReviewBranches(ctx context.Context, installationID int64) error
+}
+
+// ReviewBranches reviews all the files of the head of the default branch of every repository of the installation whose
+// full review is due, the same way as the first push of a full review interval, updating its commit status and its
+// tracking issue. The repositories without full review interval are skipped, and a repository which could not be
+// reviewed does not stop the review of the others.
+func (h *handler) ReviewBranches(ctx context.Context, installationID int64) error {
+	client, clientErr := h.clientCreator.NewInstallationClient(installationID)
+	if clientErr != nil {
+		return clientErr
+	}
+
+	repos, reposErr := reader.ListGitHubRepositories(ctx, client)
+	if reposErr != nil {
+		return reposErr
+	}
+
+	now := time.Now()
+	var errs error
+	for _, repo := range repos {
+		if repo.GetArchived() {
+			continue
+		}
+
+		if err := h.reviewBranch(ctx, client, repo, installationID, now); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("failed to review %s: %w", repo.GetFullName(), err))
+		}
+	}
+
+	return errs
+}
+
+// reviewBranch reviews all the files of the head of the default branch of the repository, as a push made at now, if
+// its full review is due.
+func (h *handler) reviewBranch(
+	ctx context.Context,
+	client *github.Client,
+	repo *github.Repository,
+	installationID int64,
+	now time.Time,
+) error {
+	ctx, logger := githubapp.PrepareRepoContext(ctx, installationID, repo)
+	owner, name, branch := repo.GetOwner().GetLogin(), repo.GetName(), repo.GetDefaultBranch()
+
+	head, _, branchErr := client.Repositories.GetBranch(ctx, owner, name, branch, 1)
+	if branchErr != nil {
+		return branchErr
+	}
+
+	sha := head.GetCommit().GetSHA()
+	target := &push{
+		pullRequest: &pullRequest{
+			repo:           repo,
+			sha:            sha,
+			baseSHA:        sha,
+			defaultBranch:  branch,
+			installationID: installationID,
+			details: &github.PullRequest{
+				Base: &github.PullRequestBranch{Ref: github.String(branch)},
+			},
+		},
+		ref:       branchRefPrefix + branch,
+		scheduled: true,
+		pushedAt:  now,
+	}
+
+	logger.Debug().Msgf("loading %s of %s", repoconfig.Path, repo.GetFullName())
+	cfg, cfgErr := repoconfig.Load(ctx, client, owner, name, sha)
+
+	// The invalid configuration is already recorded by the review of the push of the head.
+	var validationErr *repoconfig.ValidationError
+	if errors.As(cfgErr, &validationErr) {
+		logger.Info().Msgf("skipping scheduled review of %s, as %s", repo.GetFullName(), cfgErr)
+		return nil
+	}
+
+	if cfgErr != nil {
+		return cfgErr
+	}
+
+	if cfg.FullReviewInterval == "" {
+		logger.Debug().Msgf("skipping scheduled review of %s without full review interval", repo.GetFullName())
+		return nil
+	}
+
+	due, dueErr := isScheduledReviewDue(ctx, client, target, cfg)
+	if dueErr != nil {
+		return dueErr
+	}
+
+	if !due {
+		logger.Debug().Msgf("skipping scheduled review of %s reviewed within the interval", repo.GetFullName())
+		return nil
+	}
+
+	logger.Info().Msgf("reviewing all files of %s on %s on schedule", repo.GetFullName(), sha)
+	return h.reviewDefaultBranch(ctx, client, target, cfg)
+}
+
+// isScheduledReviewDue reports whether the head of the target is not reviewed within the current full review interval,
+// compared with the date of the latest commit status of the reviewer. A push reviewed within the interval is either
+// its first push, which reviewed all the files, or a push following it.
+func isScheduledReviewDue(
+	ctx context.Context,
+	client *github.Client,
+	target *push,
+	cfg *repoconfig.Config,
+) (bool, error) {
+	statuses, _, err := client.Repositories.ListStatuses(
+		ctx,
+		target.getOwner(),
+		target.getRepoName(),
+		target.sha,
+		&github.ListOptions{PerPage: numResultsPerPage},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	// Statuses are listed newest first.
+	for _, status := range statuses {
+		if status.GetContext() == checkRunName {
+			return cfg.FullReviewDue(status.GetCreatedAt().Time, target.pushedAt), nil
+		}
+	}
+
+	return true, nil
+}
+
+// NewBranchReviewer returns a BranchReviewer reviewing the files matched by the matcher with the review service.
+func NewBranchReviewer(
+	clientCreator githubapp.ClientCreator,
+	matcher filematch.Matcher,
+	reviewSvc review.Service,
+) BranchReviewer {
+	return &handler{
+		clientCreator: clientCreator,
+		matcher:       matcher,
+		reviewSvc:     reviewSvc,
+	}
+}
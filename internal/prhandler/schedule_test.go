@@ -0,0 +1,151 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchReviewer_ReviewBranches(t *testing.T) {
+	closedIssue := "<!-- opa-reviewer-tracking -->\n<!-- opa-reviewer-violations: [] -->\nAll the violations of `main` are fixed.\n" // nolint: lll
+	cases := map[string]struct {
+		archived           bool
+		config             string
+		statuses           []*github.RepoStatus
+		expectedStates     []string
+		expectedIssue      string
+		expectedIssueState string
+	}{
+		"review all files of branch never reviewed": {
+			config:             "full_review_interval: 24h",
+			statuses:           make([]*github.RepoStatus, 0),
+			expectedStates:     []string{"pending", "success"},
+			expectedIssue:      closedIssue,
+			expectedIssueState: "closed",
+		},
+		"review all files of branch reviewed in previous interval": {
+			config: "full_review_interval: 24h",
+			statuses: []*github.RepoStatus{
+				{Context: github.String("other"), CreatedAt: &github.Timestamp{Time: time.Now()}},
+				{Context: github.String(checkRunName), CreatedAt: &github.Timestamp{Time: time.Now().Add(-48 * time.Hour)}},
+			},
+			expectedStates:     []string{"pending", "success"},
+			expectedIssue:      closedIssue,
+			expectedIssueState: "closed",
+		},
+		"skip branch reviewed within interval": {
+			config: "full_review_interval: 87600h",
+			statuses: []*github.RepoStatus{
+				{Context: github.String(checkRunName), CreatedAt: &github.Timestamp{Time: time.Now()}},
+			},
+		},
+		"skip repository without full review interval": {
+			statuses: make([]*github.RepoStatus, 0),
+		},
+		"skip invalid configuration": {
+			config:   "outputs: [email]",
+			statuses: make([]*github.RepoStatus, 0),
+		},
+		"skip archived repository": {
+			archived: true,
+			config:   "full_review_interval: 24h",
+			statuses: make([]*github.RepoStatus, 0),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var states []string
+			var issue, issueState string
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(
+					mock.GetInstallationRepositories,
+					github.ListRepositories{Repositories: []*github.Repository{{
+						Name:          github.String("repo"),
+						FullName:      github.String("owner/repo"),
+						Owner:         &github.User{Login: github.String("owner")},
+						DefaultBranch: github.String("main"),
+						Archived:      github.Bool(tc.archived),
+					}}},
+				),
+				mock.WithRequestMatchHandler(
+					mock.GetReposBranchesByOwnerByRepoByBranch,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						a.Equal("/repos/owner/repo/branches/main", req.URL.Path)
+						_, _ = w.Write(mock.MustMarshal(github.Branch{
+							Commit: &github.RepositoryCommit{SHA: github.String("abcdef")},
+						}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.GetReposContentsByOwnerByRepoByPath,
+					http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+						if tc.config == "" {
+							mock.WriteError(w, http.StatusNotFound, "not found")
+							return
+						}
+
+						_, _ = w.Write(mock.MustMarshal(github.RepositoryContent{Content: github.String(tc.config)}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.GetReposCommitsStatusesByOwnerByRepoByRef,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						a.Equal("/repos/owner/repo/commits/abcdef/statuses", req.URL.Path)
+						_, _ = w.Write(mock.MustMarshal(tc.statuses))
+					}),
+				),
+				mock.WithRequestMatch(
+					mock.GetReposGitTreesByOwnerByRepoByTreeSha,
+					github.Tree{Entries: toTreeEntries([]string{"stack/file_1.yaml"})},
+				),
+				mock.WithRequestMatch(
+					mock.GetReposIssuesByOwnerByRepo,
+					toIssues(getTrackingIssue("stack/other.yaml")),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PatchReposIssuesByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						edited := decodeIssue(req)
+						issue, issueState = edited.GetBody(), edited.GetState()
+						_, _ = w.Write(mock.MustMarshal(github.Issue{
+							HTMLURL: github.String("https://github.com/owner/repo/issues/3"),
+						}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PostReposStatusesByOwnerByRepoBySha,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						defer req.Body.Close()
+						status := new(github.RepoStatus)
+						_ = json.NewDecoder(req.Body).Decode(status)
+						states = append(states, status.GetState())
+						_, _ = w.Write(mock.MustMarshal(status))
+					}),
+				),
+			))
+
+			r := NewBranchReviewer(
+				&mockClientCreator{client: client},
+				filematch.New([]string{"stack/**/*.yaml"}),
+				new(mockReviewSvc),
+			)
+
+			err := r.ReviewBranches(context.TODO(), 12345678)
+
+			a.Nil(err)
+			a.Equal(tc.expectedStates, states)
+			a.Equal(tc.expectedIssue, issue)
+			a.Equal(tc.expectedIssueState, issueState)
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package prhandler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/google/go-github/v58/github"
+)
+
+const (
+	trackingMarkerPrefix      = "<!-- opa-reviewer-tracking"
+	trackingStatePrefix       = "<!-- opa-reviewer-violations: "
+	trackingTruncatedMarker   = "<!-- opa-reviewer-violations-truncated -->"
+	trackingCommentSuffix     = " -->"
+	trackingIssueTitle        = "OPA Review violations"
+	trackingIssueLabel        = "opa-reviewer"
+	issueStateOpen            = "open"
+	issueStateClosed          = "closed"
+	issueStateReasonCompleted = "completed"
+
+	// maxIssueBody is the size accepted by GitHub for the body of an issue, of which at most maxTrackingState is taken
+	// by the hidden list of the open violations.
+	maxIssueBody     = 65536
+	maxTrackingState = 49152
+)
+
+// trackingIssue is an open tracking issue, along with the policy package it tracks, if any, and the open violations
+// it lists. The list is truncated if the violations did not all fit in the issue.
+type trackingIssue struct {
+	issue      *github.Issue
+	pkg        string
+	violations []presentation.FileViolation
+	truncated  bool
+}
+
+// trackViolations lists the open violations of the default branch in its tracking issues, a single one for the
+// repository or one per policy package depending on the repository configuration. The violations of the files which
+// were not reviewed, e.g. the ones the push did not change, are carried over from the open issues, and a full
+// review replaces all of them. Issues are only edited when their content changes, opened for the policy packages
+// with new violations and closed once all their violations are fixed. The URL of the open issues is returned, if any.
+func trackViolations(
+	ctx context.Context,
+	client *github.Client,
+	target *pullRequest,
+	cfg *repoconfig.Config,
+	issues []trackingIssue,
+	results []review.Result,
+	full bool,
+) (string, error) {
+	open := carryOverViolations(issues, results, full)
+	byPackage, existing := groupByPackage(open, issues, cfg.TrackingIssues == repoconfig.TrackingIssuesPolicy)
+	return updateTrackingIssues(ctx, client, target, byPackage, existing)
+}
+
+// carryOverViolations returns the open violations of the results, along with the previous violations of the issues
+// which are still open, i.e. the ones of the files which were not reviewed, unless the review is full.
+func carryOverViolations(issues []trackingIssue, results []review.Result, full bool) []presentation.FileViolation {
+	reviewed, failed := make(map[string]bool), make(map[string]bool)
+	for idx := range results {
+		if results[idx].IsFile() {
+			reviewed[results[idx].File] = true
+			failed[results[idx].File] = failed[results[idx].File] || results[idx].Error != nil
+		}
+	}
+
+	// The violations of the files which failed to review are unknown, so their previous violations are kept.
+	open := presentation.OpenViolations(results)
+	for _, issue := range issues {
+		for _, violation := range issue.violations {
+			if failed[violation.File] || (!full && !reviewed[violation.File]) {
+				open = append(open, violation)
+			}
+		}
+	}
+
+	presentation.SortViolations(open)
+	return open
+}
+
+// groupByPackage groups the open violations by the policy package of their tracking issue, which is the empty one of
+// the repository unless perPolicy is set, and returns the first existing issue of every package. The packages of the
+// existing issues are kept without violations, so their issues are closed.
+func groupByPackage(
+	open []presentation.FileViolation,
+	issues []trackingIssue,
+	perPolicy bool,
+) (map[string][]presentation.FileViolation, map[string]*trackingIssue) {
+	byPackage := make(map[string][]presentation.FileViolation)
+	for _, violation := range open {
+		pkg := ""
+		if perPolicy {
+			pkg = violation.Violation.Package
+		}
+
+		byPackage[pkg] = append(byPackage[pkg], violation)
+	}
+
+	existing := make(map[string]*trackingIssue)
+	for idx := range issues {
+		pkg := issues[idx].pkg
+		if _, ok := existing[pkg]; ok {
+			continue
+		}
+
+		existing[pkg] = &issues[idx]
+		if _, ok := byPackage[pkg]; !ok {
+			byPackage[pkg] = nil
+		}
+	}
+
+	return byPackage, existing
+}
+
+// updateTrackingIssues updates the tracking issue of every package with its violations, in the order of the packages,
+// and returns the URL of the open issue, or of the list of the open issues if there are several of them.
+func updateTrackingIssues(
+	ctx context.Context,
+	client *github.Client,
+	target *pullRequest,
+	byPackage map[string][]presentation.FileViolation,
+	existing map[string]*trackingIssue,
+) (string, error) {
+	pkgs := make([]string, 0, len(byPackage))
+	for pkg := range byPackage {
+		pkgs = append(pkgs, pkg)
+	}
+
+	sort.Strings(pkgs)
+	urls := make([]string, 0)
+	for _, pkg := range pkgs {
+		issueURL, err := updateTrackingIssue(ctx, client, target, existing[pkg], pkg, byPackage[pkg])
+		if err != nil {
+			return "", err
+		}
+
+		if issueURL != "" {
+			urls = append(urls, issueURL)
+		}
+	}
+
+	if len(urls) > 1 {
+		return fmt.Sprintf(
+			"%s/issues?q=%s",
+			target.repo.GetHTMLURL(),
+			url.QueryEscape("is:issue is:open label:"+trackingIssueLabel),
+		), nil
+	}
+
+	return strings.Join(urls, ""), nil
+}
+
+// updateTrackingIssue opens, edits or closes the tracking issue of the policy package, an empty package being the
+// one of the repository, with its open violations. The URL of the issue is returned if it is left open.
+func updateTrackingIssue(
+	ctx context.Context,
+	client *github.Client,
+	target *pullRequest,
+	existing *trackingIssue,
+	pkg string,
+	violations []presentation.FileViolation,
+) (string, error) {
+	body, bodyErr := renderTrackingIssue(pkg, target.defaultBranch, violations)
+	if bodyErr != nil {
+		return "", bodyErr
+	}
+
+	switch {
+	case existing == nil && len(violations) == 0:
+		return "", nil
+	case existing == nil:
+		title := trackingIssueTitle
+		if pkg != "" {
+			title = fmt.Sprintf("%s of %s", trackingIssueTitle, pkg)
+		}
+
+		created, _, err := client.Issues.Create(ctx, target.getOwner(), target.getRepoName(), &github.IssueRequest{
+			Title:  github.String(title),
+			Body:   github.String(body),
+			Labels: &[]string{trackingIssueLabel},
+		})
+
+		return created.GetHTMLURL(), err
+	case len(violations) == 0:
+		_, _, err := client.Issues.Edit(ctx, target.getOwner(), target.getRepoName(), existing.issue.GetNumber(),
+			&github.IssueRequest{
+				Body:        github.String(body),
+				State:       github.String(issueStateClosed),
+				StateReason: github.String(issueStateReasonCompleted),
+			})
+
+		return "", err
+	case body == existing.issue.GetBody():
+		return existing.issue.GetHTMLURL(), nil
+	default:
+		edited, _, err := client.Issues.Edit(ctx, target.getOwner(), target.getRepoName(), existing.issue.GetNumber(),
+			&github.IssueRequest{Body: github.String(body)})
+
+		return edited.GetHTMLURL(), err
+	}
+}
+
+// renderTrackingIssue renders the body of a tracking issue, which starts with the hidden marker of its policy package
+// followed by its open violations, both as a hidden line read back by the next review and as their presentation. Both
+// are truncated to fit in the body, in which case the hidden line is followed by the truncated marker.
+func renderTrackingIssue(pkg, branch string, violations []presentation.FileViolation) (string, error) {
+	state, truncated, err := encodeTrackingState(violations)
+	if err != nil {
+		return "", err
+	}
+
+	marker := trackingMarkerPrefix + trackingCommentSuffix
+	if pkg != "" {
+		marker = fmt.Sprintf("%s: %s%s", trackingMarkerPrefix, pkg, trackingCommentSuffix)
+	}
+
+	header := fmt.Sprintf("%s\n%s%s%s\n", marker, trackingStatePrefix, state, trackingCommentSuffix)
+	if truncated {
+		header += trackingTruncatedMarker + "\n"
+	}
+
+	return header + presentation.Truncate(presentation.TrackingIssue(branch, violations), maxIssueBody-len(header)), nil
+}
+
+// encodeTrackingState encodes the violations as a JSON array of at most maxTrackingState bytes, which keeps the first
+// violations if they do not all fit, and reports whether the array is truncated.
+func encodeTrackingState(violations []presentation.FileViolation) (string, bool, error) {
+	encoded := make([]string, 0, len(violations))
+	size := len("[]")
+	for idx := range violations {
+		violation, err := json.Marshal(violations[idx])
+		if err != nil {
+			return "", false, err
+		}
+
+		if size+len(violation)+len(",") > maxTrackingState {
+			return "[" + strings.Join(encoded, ",") + "]", true, nil
+		}
+
+		size += len(violation) + len(",")
+		encoded = append(encoded, string(violation))
+	}
+
+	return "[" + strings.Join(encoded, ",") + "]", false, nil
+}
+
+// parseTrackingIssue returns the tracking issue of an issue body starting with the hidden marker, if any.
+func parseTrackingIssue(issue *github.Issue) (*trackingIssue, bool) {
+	lines := strings.Split(issue.GetBody(), "\n")
+	marker := lines[0]
+	if !strings.HasPrefix(marker, trackingMarkerPrefix) || !strings.HasSuffix(marker, trackingCommentSuffix) {
+		return nil, false
+	}
+
+	tracking := &trackingIssue{
+		issue: issue,
+		pkg: strings.TrimPrefix(
+			strings.TrimSuffix(strings.TrimPrefix(marker, trackingMarkerPrefix), trackingCommentSuffix),
+			": ",
+		),
+	}
+
+	for idx, line := range lines[1:] {
+		if strings.HasPrefix(line, trackingStatePrefix) && strings.HasSuffix(line, trackingCommentSuffix) {
+			state := strings.TrimSuffix(strings.TrimPrefix(line, trackingStatePrefix), trackingCommentSuffix)
+			// A malformed state, e.g. edited by hand, is dropped and rebuilt by the next full review.
+			_ = json.Unmarshal([]byte(state), &tracking.violations)
+			tracking.truncated = idx+2 < len(lines) && lines[idx+2] == trackingTruncatedMarker
+			break
+		}
+	}
+
+	return tracking, true
+}
+
+// listTrackingIssues returns the open tracking issues of the repository, which are looked up by their label.
+func listTrackingIssues(ctx context.Context, client *github.Client, owner, repo string) ([]trackingIssue, error) {
+	opt := &github.IssueListByRepoOptions{
+		State:       issueStateOpen,
+		Labels:      []string{trackingIssueLabel},
+		ListOptions: github.ListOptions{PerPage: numResultsPerPage},
+	}
+
+	tracking := make([]trackingIssue, 0)
+	for {
+		issues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, issue := range issues {
+			if parsed, ok := parseTrackingIssue(issue); ok && !issue.IsPullRequest() {
+				tracking = append(tracking, *parsed)
+			}
+		}
+
+		if resp.NextPage == 0 {
+			return tracking, nil
+		}
+
+		opt.Page = resp.NextPage
+	}
+}
@@ -0,0 +1,170 @@
+package prhandler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/presentation"
+	"github.com/CameronXie/go-opa-reviewer/internal/repoconfig"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrackViolations(t *testing.T) {
+	violation := func(pkg string) reviewer.Violation {
+		return reviewer.Violation{RuleID: "rule_1", Severity: reviewer.SeverityError, Message: "violation_1", Package: pkg}
+	}
+
+	cases := map[string]struct {
+		cfg             *repoconfig.Config
+		results         []review.Result
+		full            bool
+		issues          []*github.Issue
+		expectedCreated []string
+		expectedEdited  []string
+		expectedURL     string
+	}{
+		"open repository issue with new violations": {
+			cfg: new(repoconfig.Config),
+			results: []review.Result{
+				{File: "stack/app.yaml", Decision: &reviewer.Decision{Violations: []reviewer.Violation{violation("cfn")}}},
+			},
+			expectedCreated: []string{"<!-- opa-reviewer-tracking -->\n<!-- opa-reviewer-violations: [{\"file\":\"stack/app.yaml\",\"violation\":{\"rule\":\"rule_1\",\"severity\":\"error\",\"msg\":\"violation_1\",\"package\":\"cfn\",\"document\":0,\"location\":{\"start_line\":0,\"start_column\":0,\"end_line\":0,\"end_column\":0}}}] -->\nOpen violations of `main`:\n\n* stack/app.yaml: [error] rule_1: violation_1\n"}, // nolint: lll
+			expectedURL:     "https://github.com/owner/repo/issues/1",
+		},
+		"open issue per policy package": {
+			cfg: &repoconfig.Config{TrackingIssues: repoconfig.TrackingIssuesPolicy},
+			results: []review.Result{
+				{
+					File:     "stack/app.yaml",
+					Decision: &reviewer.Decision{Violations: []reviewer.Violation{violation("cfn"), violation("prod")}},
+				},
+			},
+			expectedCreated: []string{"cfn", "prod"},
+			expectedURL:     "https://github.com/owner/repo/issues?q=is%3Aissue+is%3Aopen+label%3Aopa-reviewer",
+		},
+		"leave unchanged issue untouched": {
+			cfg: new(repoconfig.Config),
+			results: []review.Result{
+				{File: "stack/app.yaml", Decision: &reviewer.Decision{Allow: true}},
+			},
+			issues:      []*github.Issue{getTrackingIssue("stack/other.yaml")},
+			expectedURL: "https://github.com/owner/repo/issues/3",
+		},
+		"keep violations of files failed to review by full review": {
+			cfg:         new(repoconfig.Config),
+			results:     []review.Result{{File: "stack/other.yaml", Error: errors.New("invalid file")}},
+			full:        true,
+			issues:      []*github.Issue{getTrackingIssue("stack/other.yaml")},
+			expectedURL: "https://github.com/owner/repo/issues/3",
+		},
+		"close issue once its violations are fixed": {
+			cfg: &repoconfig.Config{TrackingIssues: repoconfig.TrackingIssuesPolicy},
+			results: []review.Result{
+				{File: "stack/other.yaml", Decision: &reviewer.Decision{Allow: true}},
+			},
+			issues:         []*github.Issue{getTrackingIssue("stack/other.yaml")},
+			expectedEdited: []string{"closed"},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var created, edited []string
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesByOwnerByRepo, append(make([]*github.Issue, 0), tc.issues...)),
+				mock.WithRequestMatchHandler(
+					mock.PostReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						issue := decodeIssue(req)
+						if tc.cfg.TrackingIssues == repoconfig.TrackingIssuesPolicy {
+							// Issues per policy package are told apart by their title.
+							created = append(created, issue.GetTitle()[len(trackingIssueTitle+" of "):])
+						} else {
+							created = append(created, issue.GetBody())
+						}
+
+						_, _ = w.Write(mock.MustMarshal(github.Issue{
+							HTMLURL: github.String("https://github.com/owner/repo/issues/1"),
+						}))
+					}),
+				),
+				mock.WithRequestMatchHandler(
+					mock.PatchReposIssuesByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						edited = append(edited, decodeIssue(req).GetState())
+						_, _ = w.Write(mock.MustMarshal(github.Issue{}))
+					}),
+				),
+			))
+
+			target := &pullRequest{
+				repo: &github.Repository{
+					Name:    github.String("repo"),
+					Owner:   &github.User{Login: github.String("owner")},
+					HTMLURL: github.String("https://github.com/owner/repo"),
+				},
+				sha:           "abcdef",
+				defaultBranch: "main",
+			}
+
+			issues, err := listTrackingIssues(context.TODO(), client, "owner", "repo")
+			a.Nil(err)
+
+			url, err := trackViolations(context.TODO(), client, target, tc.cfg, issues, tc.results, tc.full)
+
+			a.Nil(err)
+			a.Equal(tc.expectedCreated, created)
+			a.Equal(tc.expectedEdited, edited)
+			a.Equal(tc.expectedURL, url)
+		})
+	}
+}
+
+func TestParseTrackingIssue(t *testing.T) {
+	a := assert.New(t)
+	body, _ := renderTrackingIssue("reviewer.cfn", "main", []presentation.FileViolation{{
+		File:      "stack/app.yaml",
+		Violation: &reviewer.Violation{RuleID: "rule_1", Message: "ends with -->"},
+	}})
+
+	issue, ok := parseTrackingIssue(&github.Issue{Body: github.String(body)})
+	a.True(ok)
+	a.Equal("reviewer.cfn", issue.pkg)
+	a.Equal("ends with -->", issue.violations[0].Violation.Message)
+
+	_, ok = parseTrackingIssue(&github.Issue{Body: github.String("<!-- opa-reviewer -->\nsticky comment")})
+	a.False(ok)
+}
+
+func TestRenderTrackingIssue_Truncated(t *testing.T) {
+	a := assert.New(t)
+	violations := make([]presentation.FileViolation, 0)
+	for _, file := range getFileNames(1000) {
+		violations = append(violations, presentation.FileViolation{
+			File:      file,
+			Violation: &reviewer.Violation{RuleID: "rule_1", Severity: reviewer.SeverityError, Message: "violation_1"},
+		})
+	}
+
+	body, err := renderTrackingIssue("", "main", violations)
+	a.Nil(err)
+	a.LessOrEqual(len(body), maxIssueBody)
+	a.Contains(body, "_The output is truncated as it exceeds the size accepted by GitHub._")
+
+	issue, ok := parseTrackingIssue(&github.Issue{Body: github.String(body)})
+	a.True(ok)
+	a.True(issue.truncated)
+	a.NotEmpty(issue.violations)
+	a.Equal(violations[:len(issue.violations)], issue.violations)
+
+	issue, _ = parseTrackingIssue(getTrackingIssue("stack/app.yaml"))
+	a.False(issue.truncated)
+}
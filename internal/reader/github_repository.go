@@ -0,0 +1,32 @@
+package reader
+
+import (
+	"context"
+
+	"github.com/google/go-github/v58/github"
+)
+
+const numRepositoriesPerPage = 30
+
+// ListGitHubRepositories lists the repositories the installation of the client can access.
+func ListGitHubRepositories(ctx context.Context, client *github.Client) ([]*github.Repository, error) {
+	opt := &github.ListOptions{PerPage: numRepositoriesPerPage}
+
+	repos := make([]*github.Repository, 0)
+	for {
+		list, resp, err := client.Apps.ListRepos(ctx, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		repos = append(repos, list.Repositories...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opt.Page = resp.NextPage
+	}
+
+	return repos, nil
+}
@@ -0,0 +1,28 @@
+package reader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListGitHubRepositories(t *testing.T) {
+	a := assert.New(t)
+	client := github.NewClient(mock.NewMockedHTTPClient(
+		mock.WithRequestMatchPages(
+			mock.GetInstallationRepositories,
+			github.ListRepositories{Repositories: []*github.Repository{{FullName: github.String("owner/repo_1")}}},
+			github.ListRepositories{Repositories: []*github.Repository{{FullName: github.String("owner/repo_2")}}},
+		),
+	))
+
+	repos, err := ListGitHubRepositories(context.TODO(), client)
+
+	a.Nil(err)
+	a.Len(repos, 2)
+	a.Equal("owner/repo_1", repos[0].GetFullName())
+	a.Equal("owner/repo_2", repos[1].GetFullName())
+}
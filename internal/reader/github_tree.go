@@ -0,0 +1,27 @@
+package reader
+
+import (
+	"context"
+
+	"github.com/google/go-github/v58/github"
+)
+
+const treeEntryBlob = "blob"
+
+// ListGitHubFiles lists the paths of the files of a GitHub repository at the given ref with the Git Trees API, along
+// with whether the list is truncated, which happens when the tree exceeds the limits of the API.
+func ListGitHubFiles(ctx context.Context, client *github.Client, owner, repo, ref string) ([]string, bool, error) {
+	tree, _, err := client.Git.GetTree(ctx, owner, repo, ref, true)
+	if err != nil {
+		return nil, false, err
+	}
+
+	files := make([]string, 0, len(tree.Entries))
+	for _, entry := range tree.Entries {
+		if entry.GetType() == treeEntryBlob {
+			files = append(files, entry.GetPath())
+		}
+	}
+
+	return files, tree.GetTruncated(), nil
+}
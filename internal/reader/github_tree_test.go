@@ -0,0 +1,38 @@
+package reader
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListGitHubFiles(t *testing.T) {
+	a := assert.New(t)
+	client := github.NewClient(mock.NewMockedHTTPClient(
+		mock.WithRequestMatchHandler(
+			mock.GetReposGitTreesByOwnerByRepoByTreeSha,
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				a.Equal("/repos/owner/repo/git/trees/main", req.URL.Path)
+				a.Equal("1", req.URL.Query().Get("recursive"))
+				_, _ = w.Write(mock.MustMarshal(github.Tree{
+					Entries: []*github.TreeEntry{
+						{Path: github.String("stack"), Type: github.String("tree")},
+						{Path: github.String("stack/app.yaml"), Type: github.String("blob")},
+						{Path: github.String("vendor/lib"), Type: github.String("commit")},
+					},
+					Truncated: github.Bool(true),
+				}))
+			}),
+		),
+	))
+
+	files, truncated, err := ListGitHubFiles(context.TODO(), client, "owner", "repo", "main")
+
+	a.Nil(err)
+	a.Equal([]string{"stack/app.yaml"}, files)
+	a.True(truncated)
+}
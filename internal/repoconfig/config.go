@@ -0,0 +1,348 @@
+package repoconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/CameronXie/go-opa-reviewer/internal/reader"
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"gopkg.in/yaml.v3"
+)
+
+// Path is the path of the configuration file in a repository.
+const Path = ".github/opa-reviewer.yml"
+
+const (
+	FailOnAll = "all"
+	FailOnNew = "new"
+)
+
+const (
+	TrackingIssuesRepository = "repository"
+	TrackingIssuesPolicy     = "policy"
+)
+
+const (
+	OutputCheckRun = "check_run"
+	OutputComment  = "comment"
+	OutputReview   = "review"
+)
+
+var (
+	failOns        = []string{FailOnAll, FailOnNew}
+	trackingIssues = []string{TrackingIssuesRepository, TrackingIssuesPolicy}
+	outputs        = []string{OutputCheckRun, OutputComment, OutputReview}
+	severities     = []string{string(reviewer.SeverityInfo), string(reviewer.SeverityWarning), string(reviewer.SeverityError)}
+	actions        = []string{"opened", "reopened", "synchronize", "ready_for_review", "edited", "labeled", "unlabeled"}
+)
+
+// Config is the per-repository configuration of the reviewer. Unset fields keep the behaviour of the app.
+type Config struct {
+	// Include restricts the reviewed files to the ones matched by the ordered rules, see filematch.Rules.
+	Include []string `yaml:"include"`
+	// Exclude skips the files matching any of the rules, as if they were appended negated to Include.
+	Exclude []string `yaml:"exclude"`
+	// Packages restricts the reported violations to the ones of the policy packages, or of their sub packages.
+	Packages []string `yaml:"packages"`
+	// Severity sets the thresholds of the violations which are reported and which fail the review.
+	Severity Thresholds `yaml:"severity"`
+	// FailOn selects the violations which fail the review, all of them by default, or only the ones new to the pull
+	// request.
+	FailOn string `yaml:"fail_on"`
+	// Outputs selects where the review results are published, check_run, comment and review by default.
+	Outputs []string `yaml:"outputs"`
+	// Actions overrides the pull request actions which trigger a review.
+	Actions []string `yaml:"actions"`
+	// FullReviewInterval is the interval of the reviews of all the files of the default branch, e.g. 24h, which are
+	// run by the first push of every interval instead of reviewing the changed files only, or by the scheduled review
+	// of the branch in an interval without any push.
+	FullReviewInterval string `yaml:"full_review_interval"`
+	// TrackingIssues selects whether the open violations of the default branch are listed by a single tracking issue
+	// of the repository, the default, or by a tracking issue per policy package.
+	TrackingIssues string `yaml:"tracking_issues"`
+}
+
+// Thresholds are the lowest severities of the violations which are reported and which fail the review.
+type Thresholds struct {
+	Fail   reviewer.Severity `yaml:"fail"`
+	Report reviewer.Severity `yaml:"report"`
+}
+
+// ValidationError reports a malformed configuration file.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", Path, strings.Join(e.Problems, "; "))
+}
+
+// Load reads the configuration file of a repository at the given ref, which should be the default branch so that a
+// pull request cannot change its own configuration. The default configuration is returned if there is no file.
+func Load(ctx context.Context, client *github.Client, owner, repo, ref string) (*Config, error) {
+	content, err := reader.ReadGitHubFile(client, owner, repo, ref)(ctx, Path)
+	if err != nil {
+		var respErr *github.ErrorResponse
+		if errors.As(err, &respErr) && respErr.Response != nil && respErr.Response.StatusCode == http.StatusNotFound {
+			return new(Config), nil
+		}
+
+		return nil, fmt.Errorf("failed to fetch %s: %w", Path, err)
+	}
+
+	return Parse(content)
+}
+
+// Parse decodes and validates a configuration file, an empty file is the default configuration.
+func Parse(content []byte) (*Config, error) {
+	cfg := new(Config)
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, &ValidationError{Problems: typeErr.Errors}
+		}
+
+		return nil, &ValidationError{Problems: []string{err.Error()}}
+	}
+
+	if problems := cfg.validate(); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+// validate returns the problems found in the configuration.
+func (c *Config) validate() []string {
+	problems := make([]string, 0)
+	for field, patterns := range map[string][]string{"include": c.Include, "exclude": c.Exclude} {
+		for idx, pattern := range patterns {
+			if err := filematch.Validate(pattern); err != nil {
+				problems = append(problems, fmt.Sprintf("%s[%d]: invalid pattern %q", field, idx, pattern))
+			}
+		}
+	}
+
+	for _, field := range c.enumFields() {
+		problems = append(problems, field.validate()...)
+	}
+
+	for idx, pkg := range c.Packages {
+		if pkg == "" {
+			problems = append(problems, fmt.Sprintf("packages[%d]: must not be empty", idx))
+		}
+	}
+
+	interval, intervalErr := time.ParseDuration(c.FullReviewInterval)
+	if c.FullReviewInterval != "" && (intervalErr != nil || interval <= 0) {
+		problems = append(problems, "full_review_interval: must be a positive duration, e.g. 24h")
+	}
+
+	sort.Strings(problems)
+	return problems
+}
+
+// enumField is a field of the configuration whose values must be one of the allowed ones. The values of a list are
+// reported with their index, while an unset scalar is the default.
+type enumField struct {
+	name    string
+	values  []string
+	allowed []string
+	list    bool
+}
+
+// enumFields returns the fields of the configuration which only accept the allowed values.
+func (c *Config) enumFields() []enumField {
+	return []enumField{
+		{name: "severity.fail", values: []string{string(c.Severity.Fail)}, allowed: severities},
+		{name: "severity.report", values: []string{string(c.Severity.Report)}, allowed: severities},
+		{name: "fail_on", values: []string{c.FailOn}, allowed: failOns},
+		{name: "tracking_issues", values: []string{c.TrackingIssues}, allowed: trackingIssues},
+		{name: "outputs", values: c.Outputs, allowed: outputs, list: true},
+		{name: "actions", values: c.Actions, allowed: actions, list: true},
+	}
+}
+
+// validate returns the problems found in the values of the field.
+func (f *enumField) validate() []string {
+	problems := make([]string, 0)
+	for idx, value := range f.values {
+		if (value == "" && !f.list) || contains(f.allowed, value) {
+			continue
+		}
+
+		name := f.name
+		if f.list {
+			name = fmt.Sprintf("%s[%d]", f.name, idx)
+		}
+
+		problems = append(problems, fmt.Sprintf("%s: must be one of %s", name, join(f.allowed)))
+	}
+
+	return problems
+}
+
+// SupportsAction reports whether a pull request action can be configured to trigger a review.
+func SupportsAction(action string) bool {
+	return contains(actions, action)
+}
+
+// Matches reports whether a file, with its change status, is included and not excluded by the configuration.
+func (c *Config) Matches(name, status string) bool {
+	return filematch.New(c.rules()).Match(name, status)
+}
+
+// rules returns the include rules, all files by default, followed by the negated exclude rules.
+func (c *Config) rules() []string {
+	rules := []string{"**"}
+	if len(c.Include) > 0 {
+		rules = append([]string(nil), c.Include...)
+	}
+
+	for _, pattern := range c.Exclude {
+		rules = append(rules, "!"+strings.TrimPrefix(pattern, "!"))
+	}
+
+	return rules
+}
+
+// HandlesAction reports whether a pull request action triggers a review, defaults are used if no action is configured.
+func (c *Config) HandlesAction(action string, defaults []string) bool {
+	if len(c.Actions) == 0 {
+		return contains(defaults, action)
+	}
+
+	return contains(c.Actions, action)
+}
+
+// FullReviewDue reports whether a review at current, following a commit or a review at previous, is the first one of a
+// full review interval. Intervals are aligned on the zero time, so an interval of 24h starts every day at midnight UTC.
+func (c *Config) FullReviewDue(previous, current time.Time) bool {
+	interval, err := time.ParseDuration(c.FullReviewInterval)
+	if err != nil || interval <= 0 {
+		return false
+	}
+
+	return !previous.Truncate(interval).Equal(current.Truncate(interval))
+}
+
+// HasOutput reports whether the review results are published to the given output.
+func (c *Config) HasOutput(output string) bool {
+	return len(c.Outputs) == 0 || contains(c.Outputs, output)
+}
+
+// Apply filters the violations of the results by the enabled packages and the report threshold, and disallows the
+// files with violations at or above the fail threshold, only considering the new violations if FailOn is new.
+// The results are copied rather than modified.
+func (c *Config) Apply(results []review.Result) []review.Result {
+	applied := make([]review.Result, 0, len(results))
+	for idx := range results {
+		result := results[idx]
+		if result.Decision != nil {
+			result.Decision = c.applyDecision(result.Decision)
+		}
+
+		applied = append(applied, result)
+	}
+
+	return applied
+}
+
+func (c *Config) applyDecision(decision *reviewer.Decision) *reviewer.Decision {
+	applied := *decision
+	applied.Violations = c.reported(decision.Violations)
+	if decision.Fixed != nil {
+		applied.Fixed = c.reported(decision.Fixed)
+	}
+
+	failing := &reviewer.Decision{Violations: applied.Violations}
+	if c.FailOn == FailOnNew {
+		failing.Violations = newViolations(applied.Violations)
+	}
+
+	if len(c.Packages) > 0 || c.FailOn == FailOnNew {
+		// The allow flags of the disabled packages, or of the base content, are unknown, so the outcome is decided by
+		// the severities.
+		applied.Allow = failing.HighestSeverity() != reviewer.SeverityError
+	}
+
+	if c.Severity.Fail != "" && failing.HighestSeverity().Rank() >= c.Severity.Fail.Rank() {
+		applied.Allow = false
+	}
+
+	return &applied
+}
+
+// reported returns the violations of the enabled packages at or above the report threshold.
+func (c *Config) reported(violations []reviewer.Violation) []reviewer.Violation {
+	reported := make([]reviewer.Violation, 0, len(violations))
+	for idx := range violations {
+		violation := violations[idx]
+		if c.enabledPackage(violation.Package) && violation.Severity.Rank() >= c.Severity.Report.Rank() {
+			reported = append(reported, violation)
+		}
+	}
+
+	return reported
+}
+
+// enabledPackage reports whether a package, or one of its parent packages, is enabled.
+func (c *Config) enabledPackage(pkg string) bool {
+	if len(c.Packages) == 0 {
+		return true
+	}
+
+	for _, enabled := range c.Packages {
+		enabled = strings.TrimPrefix(enabled, "data.")
+		if pkg == enabled || strings.HasPrefix(pkg, enabled+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newViolations returns the violations which are not found in the base content, violations which were not classified
+// are new as well.
+func newViolations(violations []reviewer.Violation) []reviewer.Violation {
+	found := make([]reviewer.Violation, 0, len(violations))
+	for idx := range violations {
+		if violations[idx].Change != reviewer.ViolationUnchanged {
+			found = append(found, violations[idx])
+		}
+	}
+
+	return found
+}
+
+func contains[T comparable](s []T, item T) bool {
+	for idx := range s {
+		if s[idx] == item {
+			return true
+		}
+	}
+
+	return false
+}
+
+func join[T ~string](values []T) string {
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		strs = append(strs, string(value))
+	}
+
+	return strings.Join(strs, ", ")
+}
@@ -0,0 +1,278 @@
+package repoconfig
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/CameronXie/go-opa-reviewer/internal/review"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/google/go-github/v58/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected *Config
+		errMsg   *string
+	}{
+		"empty config": {
+			content:  "",
+			expected: new(Config),
+		},
+		"full config": {
+			content: `
+include: [stack/**/*.yaml]
+exclude: [stack/legacy/**]
+packages: [reviewer.cfn]
+severity:
+  fail: warning
+  report: warning
+fail_on: new
+outputs: [check_run, comment]
+actions: [opened, synchronize]
+full_review_interval: 24h
+tracking_issues: policy
+`,
+			expected: &Config{
+				Include:            []string{"stack/**/*.yaml"},
+				Exclude:            []string{"stack/legacy/**"},
+				Packages:           []string{"reviewer.cfn"},
+				Severity:           Thresholds{Fail: reviewer.SeverityWarning, Report: reviewer.SeverityWarning},
+				FailOn:             FailOnNew,
+				Outputs:            []string{"check_run", "comment"},
+				Actions:            []string{"opened", "synchronize"},
+				FullReviewInterval: "24h",
+				TrackingIssues:     TrackingIssuesPolicy,
+			},
+		},
+		"unknown field should return error": {
+			content: "patterns: [stack/**/*.yaml]",
+			errMsg:  strPtr("invalid .github/opa-reviewer.yml: line 1: field patterns not found in type repoconfig.Config"),
+		},
+		"invalid values should return error": {
+			content: `
+include: ["stack/[.yaml"]
+severity: {fail: critical}
+fail_on: changed
+outputs: [email]
+actions: [closed]
+packages: [""]
+full_review_interval: daily
+tracking_issues: file
+`,
+			errMsg: strPtr("invalid .github/opa-reviewer.yml: " +
+				"actions[0]: must be one of opened, reopened, synchronize, ready_for_review, edited, labeled, unlabeled; " +
+				"fail_on: must be one of all, new; " +
+				"full_review_interval: must be a positive duration, e.g. 24h; " +
+				"include[0]: invalid pattern \"stack/[.yaml\"; " +
+				"outputs[0]: must be one of check_run, comment, review; " +
+				"packages[0]: must not be empty; " +
+				"severity.fail: must be one of info, warning, error; " +
+				"tracking_issues: must be one of repository, policy"),
+		},
+		"invalid yaml should return error": {
+			content: "include: stack",
+			errMsg:  strPtr("invalid .github/opa-reviewer.yml: line 1: cannot unmarshal !!str `stack` into []string"),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			cfg, err := Parse([]byte(tc.content))
+
+			if tc.errMsg != nil {
+				a.EqualError(err, *tc.errMsg)
+				return
+			}
+
+			a.NoError(err)
+			a.Equal(tc.expected, cfg)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cases := map[string]struct {
+		status   int
+		content  string
+		expected *Config
+		errMsg   *string
+	}{
+		"load config": {
+			status:   http.StatusOK,
+			content:  "outputs: [comment]",
+			expected: &Config{Outputs: []string{"comment"}},
+		},
+		"missing config should return default config": {
+			status:   http.StatusNotFound,
+			expected: new(Config),
+		},
+		"failed to fetch config should return error": {
+			status: http.StatusInternalServerError,
+			errMsg: strPtr("failed to fetch .github/opa-reviewer.yml"),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var ref string
+
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposContentsByOwnerByRepoByPath,
+					http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						ref = req.URL.Query().Get("ref")
+						if tc.status != http.StatusOK {
+							mock.WriteError(w, tc.status, "error")
+							return
+						}
+
+						_, _ = w.Write(mock.MustMarshal(github.RepositoryContent{Content: github.String(tc.content)}))
+					}),
+				),
+			))
+
+			cfg, err := Load(context.TODO(), client, "owner", "repo", "main")
+			a.Equal("main", ref)
+
+			if tc.errMsg != nil {
+				a.ErrorContains(err, *tc.errMsg)
+				return
+			}
+
+			a.NoError(err)
+			a.Equal(tc.expected, cfg)
+		})
+	}
+}
+
+func TestConfig_Matches(t *testing.T) {
+	cfg := &Config{Include: []string{"stack/**/*.yaml"}, Exclude: []string{"stack/legacy/**"}}
+
+	a := assert.New(t)
+	a.True(cfg.Matches("stack/app/template.yaml", "modified"))
+	a.False(cfg.Matches("stack/legacy/template.yaml", "modified"))
+	a.False(cfg.Matches("k8s/deployment.yaml", "modified"))
+	a.True(new(Config).Matches("k8s/deployment.yaml", "modified"))
+
+	ordered := &Config{
+		Include: []string{"stack/**", "!stack/**/fixtures/**", "stack/**/fixtures/keep.yaml"},
+		Exclude: []string{"removed:**"},
+	}
+	a.True(ordered.Matches("stack/app/fixtures/keep.yaml", "added"))
+	a.False(ordered.Matches("stack/app/fixtures/other.yaml", "added"))
+	a.False(ordered.Matches("stack/app/template.yaml", "removed"))
+}
+
+func TestConfig_HandlesAction(t *testing.T) {
+	a := assert.New(t)
+	defaults := []string{"opened"}
+
+	a.True(new(Config).HandlesAction("opened", defaults))
+	a.False(new(Config).HandlesAction("labeled", defaults))
+	a.True((&Config{Actions: []string{"labeled"}}).HandlesAction("labeled", defaults))
+	a.False((&Config{Actions: []string{"labeled"}}).HandlesAction("opened", defaults))
+}
+
+func TestConfig_FullReviewDue(t *testing.T) {
+	a := assert.New(t)
+	daily := &Config{FullReviewInterval: "24h"}
+	previous := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+
+	a.True(daily.FullReviewDue(previous, time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC)))
+	a.False(daily.FullReviewDue(previous, time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)))
+	a.False(new(Config).FullReviewDue(previous, time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC)))
+}
+
+func TestConfig_HasOutput(t *testing.T) {
+	a := assert.New(t)
+
+	a.True(new(Config).HasOutput(OutputReview))
+	a.True((&Config{Outputs: []string{OutputComment}}).HasOutput(OutputComment))
+	a.False((&Config{Outputs: []string{OutputComment}}).HasOutput(OutputCheckRun))
+}
+
+func TestConfig_Apply(t *testing.T) {
+	violations := []reviewer.Violation{
+		{RuleID: "rule_1", Package: "reviewer.cfn", Severity: reviewer.SeverityError},
+		{RuleID: "rule_2", Package: "reviewer.cfn.iam", Severity: reviewer.SeverityWarning},
+		{RuleID: "rule_3", Package: "reviewer.k8s", Severity: reviewer.SeverityInfo},
+	}
+
+	cases := map[string]struct {
+		cfg      *Config
+		decision *reviewer.Decision
+		expected *reviewer.Decision
+	}{
+		"default config keeps decision": {
+			cfg:      new(Config),
+			decision: &reviewer.Decision{Allow: true, Violations: violations[1:]},
+			expected: &reviewer.Decision{Allow: true, Violations: violations[1:]},
+		},
+		"filter violations by packages": {
+			cfg:      &Config{Packages: []string{"data.reviewer.k8s"}},
+			decision: &reviewer.Decision{Allow: false, Violations: violations},
+			expected: &reviewer.Decision{Allow: true, Violations: violations[2:]},
+		},
+		"filter violations by report threshold": {
+			cfg:      &Config{Severity: Thresholds{Report: reviewer.SeverityWarning}},
+			decision: &reviewer.Decision{Allow: false, Violations: violations},
+			expected: &reviewer.Decision{Allow: false, Violations: violations[:2]},
+		},
+		"fail on warnings": {
+			cfg:      &Config{Packages: []string{"reviewer.cfn"}, Severity: Thresholds{Fail: reviewer.SeverityWarning}},
+			decision: &reviewer.Decision{Allow: true, Violations: violations[1:]},
+			expected: &reviewer.Decision{Allow: false, Violations: violations[1:2]},
+		},
+		"fail on new violations": {
+			cfg: &Config{FailOn: FailOnNew},
+			decision: &reviewer.Decision{
+				Allow:      false,
+				Violations: []reviewer.Violation{withChange(violations[0], "unchanged"), withChange(violations[1], "new")},
+				Fixed:      []reviewer.Violation{withChange(violations[2], "fixed")},
+			},
+			expected: &reviewer.Decision{
+				Allow:      true,
+				Violations: []reviewer.Violation{withChange(violations[0], "unchanged"), withChange(violations[1], "new")},
+				Fixed:      []reviewer.Violation{withChange(violations[2], "fixed")},
+			},
+		},
+		"fail on new violations above fail threshold": {
+			cfg: &Config{FailOn: FailOnNew, Severity: Thresholds{Fail: reviewer.SeverityWarning, Report: "warning"}},
+			decision: &reviewer.Decision{
+				Allow:      false,
+				Violations: []reviewer.Violation{withChange(violations[0], "unchanged"), withChange(violations[1], "new")},
+				Fixed:      []reviewer.Violation{withChange(violations[2], "fixed")},
+			},
+			expected: &reviewer.Decision{
+				Allow:      false,
+				Violations: []reviewer.Violation{withChange(violations[0], "unchanged"), withChange(violations[1], "new")},
+				Fixed:      []reviewer.Violation{},
+			},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			results := []review.Result{{File: "file-1", Decision: tc.decision}, {File: "file-2"}}
+
+			a.Equal([]review.Result{{File: "file-1", Decision: tc.expected}, {File: "file-2"}}, tc.cfg.Apply(results))
+		})
+	}
+}
+
+func withChange(violation reviewer.Violation, change reviewer.ViolationChange) reviewer.Violation {
+	violation.Change = change
+	return violation
+}
+
+func strPtr(str string) *string {
+	return &str
+}
@@ -0,0 +1,30 @@
+package review
+
+import (
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+)
+
+// HighestSeverity returns the highest severity found across the decisions of the given results.
+func HighestSeverity(results []Result) reviewer.Severity {
+	var severity reviewer.Severity
+	for idx := range results {
+		if results[idx].Decision == nil {
+			continue
+		}
+
+		severity = severity.Max(results[idx].Decision.HighestSeverity())
+	}
+
+	return severity
+}
+
+// Allowed reports whether every reviewed file in the given results is allowed by the policies.
+func Allowed(results []Result) bool {
+	for idx := range results {
+		if results[idx].Decision != nil && !results[idx].Decision.Allow {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,56 @@
+package review
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighestSeverityAndAllowed(t *testing.T) {
+	cases := map[string]struct {
+		results          []Result
+		expectedSeverity reviewer.Severity
+		expectedAllowed  bool
+	}{
+		"no results": {
+			results:         []Result{},
+			expectedAllowed: true,
+		},
+		"results with warnings": {
+			results: []Result{
+				{File: "file_1", Decision: &reviewer.Decision{Allow: true}},
+				{File: "file_2", Decision: &reviewer.Decision{
+					Allow:      true,
+					Violations: []reviewer.Violation{{Severity: reviewer.SeverityWarning}},
+				}},
+				{File: "file_3", Error: errors.New("invalid")},
+			},
+			expectedSeverity: reviewer.SeverityWarning,
+			expectedAllowed:  true,
+		},
+		"results with errors": {
+			results: []Result{
+				{File: "file_1", Decision: &reviewer.Decision{
+					Allow:      true,
+					Violations: []reviewer.Violation{{Severity: reviewer.SeverityInfo}},
+				}},
+				{File: "file_2", Decision: &reviewer.Decision{
+					Allow:      false,
+					Violations: []reviewer.Violation{{Severity: reviewer.SeverityError}},
+				}},
+			},
+			expectedSeverity: reviewer.SeverityError,
+			expectedAllowed:  false,
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			a.Equal(tc.expectedSeverity, HighestSeverity(tc.results))
+			a.Equal(tc.expectedAllowed, Allowed(tc.results))
+		})
+	}
+}
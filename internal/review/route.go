@@ -0,0 +1,73 @@
+package review
+
+import (
+	"github.com/CameronXie/go-opa-reviewer/internal/filematch"
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+)
+
+// Route maps the files matching its ordered glob patterns to the Reviewer of a policy package, see filematch.Rules
+// for the syntax of the patterns. Routes with Types only review the files of one of the detected types, and routes
+// with Envelope give their policies the documents wrapped in the envelope of the review, see reviewer.WithEnvelope.
+type Route struct {
+	Name     string
+	Patterns []string
+	Types    []reviewer.FileType
+	Envelope bool
+	Reviewer reviewer.Reviewer
+}
+
+// Match checks if the given file, with its change status, is matched by the route patterns.
+func (r *Route) Match(name, status string) bool {
+	return filematch.New(r.Patterns).Match(name, status)
+}
+
+// Accepts checks if the route reviews files of the given detected type.
+func (r *Route) Accepts(fileType reviewer.FileType) bool {
+	if len(r.Types) == 0 {
+		return true
+	}
+
+	for _, t := range r.Types {
+		if t == fileType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matcher returns a matcher of the files matched by any of the given routes.
+func Matcher(routes []Route) filematch.Matcher {
+	matchers := make(filematch.Any, 0, len(routes))
+	for idx := range routes {
+		matchers = append(matchers, &routes[idx])
+	}
+
+	return matchers
+}
+
+// matchRoutes returns the routes which match the given file.
+func matchRoutes(routes []Route, name, status string) []*Route {
+	matched := make([]*Route, 0)
+	for idx := range routes {
+		if routes[idx].Match(name, status) {
+			matched = append(matched, &routes[idx])
+		}
+	}
+
+	return matched
+}
+
+// routeFile returns the routes which match the given read file and accept its detected type. The type of a removed
+// file is unknown when its base content is not read, so it is routed to every matching route rather than to none.
+func routeFile(routes []Route, file *File) []*Route {
+	untyped := file.Status == reviewer.ChangeStatusRemoved && file.Base == nil
+	matched := make([]*Route, 0)
+	for _, route := range matchRoutes(routes, file.Name, file.Status) {
+		if untyped || route.Accepts(file.Type) {
+			matched = append(matched, route)
+		}
+	}
+
+	return matched
+}
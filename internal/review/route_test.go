@@ -0,0 +1,69 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoute_Match(t *testing.T) {
+	cases := map[string]struct {
+		patterns []string
+		file     string
+		status   string
+		expected bool
+	}{
+		"match nested file": {
+			patterns: []string{"k8s/*.yaml", "stack/**/*.yaml"},
+			file:     "stack/app/file_1.yaml",
+			expected: true,
+		},
+		"not match file": {
+			patterns: []string{"stack/**/*.yaml"},
+			file:     "k8s/file_1.yaml",
+		},
+		"not match without patterns": {
+			file: "stack/file_1.yaml",
+		},
+		"not match negated file": {
+			patterns: []string{"stack/**/*.yaml", "!stack/**/fixtures/**"},
+			file:     "stack/app/fixtures/file_1.yaml",
+		},
+		"not match excluded status": {
+			patterns: []string{"stack/**/*.yaml", "!removed:**"},
+			file:     "stack/file_1.yaml",
+			status:   "removed",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			route := Route{Name: "route", Patterns: tc.patterns}
+			assert.Equal(t, tc.expected, route.Match(tc.file, tc.status))
+		})
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	a := assert.New(t)
+	matcher := Matcher([]Route{
+		{Name: "cfn", Patterns: []string{"stack/**/*.yaml", "!removed:**"}},
+		{Name: "k8s", Patterns: []string{"k8s/*.yaml", ".github/workflows/*.yml"}},
+	})
+
+	a.True(matcher.Match("stack/app/file_1.yaml", "added"))
+	a.True(matcher.Match(".github/workflows/ci.yml", "removed"))
+	a.False(matcher.Match("stack/app/file_1.yaml", "removed"))
+	a.False(matcher.Match("docs/file_1.md", "added"))
+}
+
+func TestRoute_Accepts(t *testing.T) {
+	a := assert.New(t)
+	route := Route{Name: "k8s", Types: []reviewer.FileType{reviewer.FileTypeKubernetes}}
+
+	a.True(route.Accepts(reviewer.FileTypeKubernetes))
+	a.False(route.Accepts(reviewer.FileTypeCloudFormation))
+	a.False(route.Accepts(reviewer.FileTypeUnknown))
+	a.True(new(Route).Accepts(reviewer.FileTypeUnknown))
+}
@@ -11,35 +11,141 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// ChangedFile is a file changed by a pull request, with its status as reported by GitHub, e.g. added or removed,
+// its name before it was renamed, and the number of its added and deleted lines.
+type ChangedFile struct {
+	Name         string
+	Status       string
+	PreviousName string
+	Additions    int
+	Deletions    int
+}
+
+// File is a read changed file, along with the type detected from its content. Removed files have no content.
+// Base is the content of the file before the change, which is nil for added files or if it was not read.
 type File struct {
-	Name    string
+	ChangedFile
+	Type    reviewer.FileType
 	Content []byte
+	Base    []byte
 }
 
 type Result struct {
-	File   string
-	Output []byte
-	Error  error
+	File     string
+	Route    string
+	Decision *reviewer.Decision
+	Error    error
+}
+
+const (
+	// ChangesetRoute is the route of the result of reviewing the changed files as a whole, which has no file.
+	ChangesetRoute = "changeset"
+
+	// PullRequestRoute is the route of the result of reviewing the metadata of a pull request, which has no file.
+	PullRequestRoute = "pull_request"
+
+	statusAdded = "added"
+)
+
+// IsFile reports whether the result is the review of a single file, rather than of the changed files as a whole or of
+// the pull request.
+func (r *Result) IsFile() bool {
+	return r.File != ""
 }
 
 type ReadFileFunc func(ctx context.Context, file string) ([]byte, error)
 
 type Service interface {
-	Review(context.Context, ReadFileFunc, []string) ([]Result, error)
+	Review(context.Context, ReadFileFunc, []ChangedFile, ...Option) ([]Result, error)
+}
+
+// Option configures a single review of changed files.
+type Option func(*options)
+
+type options struct {
+	readBase      ReadFileFunc
+	changedFiles  []ChangedFile
+	envelope      reviewer.Envelope
+	skipChangeset bool
+}
+
+// WithBase reviews the changed files against their content before the change, e.g. on the base branch of a pull
+// request, read with the given function. See reviewer.WithBase.
+func WithBase(read ReadFileFunc) Option {
+	return func(o *options) {
+		o.readBase = read
+	}
+}
+
+// WithChangedFiles lists all the changed files to the changeset reviewer, including the ones which are not reviewed on
+// their own, e.g. a CHANGELOG.md. The reviewed files are listed by default.
+func WithChangedFiles(files []ChangedFile) Option {
+	return func(o *options) {
+		o.changedFiles = files
+	}
+}
+
+// WithEnvelope gives the context of the changed files, e.g. their repository and pull request, to the routes which
+// review the documents wrapped in an envelope. The envelope of these routes is empty otherwise.
+func WithEnvelope(envelope reviewer.Envelope) Option {
+	return func(o *options) {
+		o.envelope = envelope
+	}
+}
+
+// WithoutChangeset skips the review of the files as a whole, e.g. when every file of a branch is reviewed rather than
+// the files changed by a pull request or a push.
+func WithoutChangeset() Option {
+	return func(o *options) {
+		o.skipChangeset = true
+	}
+}
+
+// changeset returns the changed files listed to the changeset reviewer, which are the reviewed files by default.
+func (o *options) changeset(reviewed []ChangedFile) []ChangedFile {
+	if o.changedFiles == nil {
+		return reviewed
+	}
+
+	return o.changedFiles
+}
+
+// ServiceOption configures the optional behaviours of the review service.
+type ServiceOption func(*service)
+
+// WithChangesetReviewer reviews the changed files as a whole once every file is reviewed, and reports the decision
+// alongside the file results under the changeset route.
+func WithChangesetReviewer(changeset reviewer.ChangesetReviewer) ServiceOption {
+	return func(s *service) {
+		s.changeset = changeset
+	}
+}
+
+type reviewTask struct {
+	file  File
+	route *Route
 }
 
 type service struct {
 	readerPoolSize   int
 	reviewerPoolSize int
-	fileReviewer     reviewer.Reviewer
+	routes           []Route
+	changeset        reviewer.ChangesetReviewer
 }
 
 // Review is a method that orchestrates the file reading and file reviewing processes.
 // It creates goroutine pools for reading files and reviewing files, and manages the communication between them through channels.
 // The method takes a ReadFileFunc, which is a function for reading files,
-// and a slice of paths representing the files to be read and reviewed.
+// and a slice of changed files to be read and reviewed.
+// Every file is reviewed by each route it matches, files which match no route are skipped.
+// The changed files are then reviewed as a whole if a changeset reviewer is set, unless the changeset is skipped.
 // It returns a slice of Result containing the output of the review process for each file, and an error if any occurred.
-func (s *service) Review(ctx context.Context, read ReadFileFunc, paths []string) ([]Result, error) {
+func (s *service) Review(ctx context.Context, read ReadFileFunc, files []ChangedFile, opts ...Option) ([]Result, error) {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	fileChan := make(chan File)
 	resultChan := make(chan Result)
 	errorChan := make(chan error)
@@ -47,20 +153,21 @@ func (s *service) Review(ctx context.Context, read ReadFileFunc, paths []string)
 	var readerWG sync.WaitGroup
 	var reviewerWG sync.WaitGroup
 
-	readerPool, readerPoolErr := s.setupReaderPoolWithFunc(ctx, &readerWG, read, fileChan, resultChan)
+	readerPool, readerPoolErr := s.setupReaderPoolWithFunc(ctx, &readerWG, read, o.readBase, fileChan, resultChan)
 	if readerPoolErr != nil {
 		return nil, readerPoolErr
 	}
 	defer readerPool.Release()
 
-	reviewerPool, reviewerPoolErr := s.setupReviewerPoolWithFunc(ctx, &reviewerWG, resultChan)
+	reviewerPool, reviewerPoolErr := s.setupReviewerPoolWithFunc(ctx, &reviewerWG, o, resultChan)
 	if reviewerPoolErr != nil {
 		return nil, reviewerPoolErr
 	}
 	defer reviewerPool.Release()
 
-	go s.readFile(&readerWG, readerPool, paths, fileChan, errorChan)
-	go s.reviewFile(&reviewerWG, reviewerPool, fileChan, resultChan, errorChan)
+	readFiles := make(map[string]File)
+	go s.readFile(&readerWG, readerPool, files, fileChan, errorChan)
+	go s.reviewFile(&reviewerWG, reviewerPool, fileChan, readFiles, resultChan, errorChan)
 
 	results := make([]Result, 0)
 	var errs error
@@ -70,6 +177,10 @@ func (s *service) Review(ctx context.Context, read ReadFileFunc, paths []string)
 		select {
 		case result, ok := <-resultChan:
 			if !ok {
+				if s.changeset != nil && !o.skipChangeset {
+					results = append(results, s.reviewChangeset(ctx, o.changeset(files), readFiles))
+				}
+
 				return results, errs
 			}
 
@@ -82,11 +193,13 @@ func (s *service) Review(ctx context.Context, read ReadFileFunc, paths []string)
 	}
 }
 
-// setupReaderPoolWithFunc is a method that creates and configures a pool of goroutines to execute the read function.
+// setupReaderPoolWithFunc is a method that creates and configures a pool of goroutines to execute the read function,
+// and the readBase function if any.
 func (s *service) setupReaderPoolWithFunc(
 	ctx context.Context,
 	wg *sync.WaitGroup,
 	read ReadFileFunc,
+	readBase ReadFileFunc,
 	fileChan chan<- File,
 	resultChan chan<- Result,
 ) (*ants.PoolWithFunc, error) {
@@ -95,65 +208,111 @@ func (s *service) setupReaderPoolWithFunc(
 	return ants.NewPoolWithFunc(s.readerPoolSize, func(input any) {
 		defer wg.Done()
 
-		path := input.(string)
-		logger.Debug().Msgf("reading file from %s", path)
+		file := File{ChangedFile: input.(ChangedFile)}
+		if file.Status != reviewer.ChangeStatusRemoved {
+			logger.Debug().Msgf("reading file from %s", file.Name)
 
-		content, err := read(ctx, path)
-		if err != nil {
-			resultChan <- Result{
-				File:  path,
-				Error: processFileErr(err, "read"),
+			content, err := read(ctx, file.Name)
+			if err != nil {
+				resultChan <- Result{
+					File:  file.Name,
+					Error: processFileErr(err, "read"),
+				}
+				return
 			}
-			return
+
+			file.Type = reviewer.DetectFileType(content)
+			file.Content = content
 		}
 
-		fileChan <- File{
-			Name:    path,
-			Content: content,
+		if basePath, ok := file.basePath(); ok && readBase != nil {
+			logger.Debug().Msgf("reading base file from %s", basePath)
+
+			base, err := readBase(ctx, basePath)
+			if err != nil {
+				resultChan <- Result{
+					File:  file.Name,
+					Error: processFileErr(err, "read base"),
+				}
+				return
+			}
+
+			file.Base = base
+			if file.Status == reviewer.ChangeStatusRemoved {
+				// The type of a removed file is the one of its last content, so it is routed as it was before.
+				file.Type = reviewer.DetectFileType(base)
+			}
 		}
+
+		fileChan <- file
 	}, ants.WithLogger(logger))
 }
 
-// setupReviewerPoolWithFunc is a method that creates a goroutine pool with a function to review files.
+// setupReviewerPoolWithFunc is a method that creates a goroutine pool with a function to review files, against their
+// base content if the base is read, and wrapped in the envelope for the routes which require it.
 func (s *service) setupReviewerPoolWithFunc(
 	ctx context.Context,
 	wg *sync.WaitGroup,
+	o *options,
 	resultChan chan<- Result,
 ) (*ants.PoolWithFunc, error) {
 	logger := zerolog.Ctx(ctx)
 	return ants.NewPoolWithFunc(s.reviewerPoolSize, func(input any) {
 		defer wg.Done()
 
-		file := input.(File)
-		logger.Debug().Msgf("reviewing file %s", file.Name)
+		task := input.(reviewTask)
+		logger.Debug().Msgf("reviewing file %s with route %s", task.file.Name, task.route.Name)
 
-		result, err := s.fileReviewer.Review(context.TODO(), file.Content)
+		opts := []reviewer.Option{reviewer.WithChange(reviewer.Change{
+			Path:         task.file.Name,
+			Status:       task.file.Status,
+			PreviousPath: task.file.PreviousName,
+			Additions:    task.file.Additions,
+			Deletions:    task.file.Deletions,
+		})}
+
+		if o.readBase != nil {
+			opts = append(opts, reviewer.WithBase(task.file.Base))
+		}
+
+		if task.route.Envelope {
+			opts = append(opts, reviewer.WithEnvelope(o.envelope))
+		}
+
+		decision, err := task.route.Reviewer.Review(context.TODO(), task.file.Content, opts...)
 		if err != nil {
 			resultChan <- Result{
-				File:  file.Name,
+				File:  task.file.Name,
+				Route: task.route.Name,
 				Error: processFileErr(err, "review"),
 			}
 			return
 		}
 
 		resultChan <- Result{
-			File:   file.Name,
-			Output: result,
+			File:     task.file.Name,
+			Route:    task.route.Name,
+			Decision: decision,
 		}
 	}, ants.WithLogger(logger))
 }
 
-// readFile is a method that loop through paths, reads files and sends them to the fileChan channel for processing.
+// readFile is a method that loop through files, reads them and sends them to the fileChan channel for processing.
+// The content of the removed files is not read.
 func (s *service) readFile(
 	wg *sync.WaitGroup,
 	pool *ants.PoolWithFunc,
-	paths []string,
+	files []ChangedFile,
 	fileChan chan<- File,
 	errorChan chan<- error,
 ) {
-	for idx := range paths {
+	for idx := range files {
+		if len(matchRoutes(s.routes, files[idx].Name, files[idx].Status)) == 0 {
+			continue
+		}
+
 		wg.Add(1)
-		if err := pool.Invoke(paths[idx]); err != nil {
+		if err := pool.Invoke(files[idx]); err != nil {
 			wg.Done()
 			errorChan <- taskSubmitErr(err, "reader")
 			continue
@@ -164,20 +323,25 @@ func (s *service) readFile(
 	close(fileChan)
 }
 
-// reviewFile is a goroutine that processes the files received from the fileChan channel.
+// reviewFile is a goroutine that processes the files received from the fileChan channel with every matching route
+// which accepts the detected type of the file. The files are recorded in readFiles by their name.
 func (s *service) reviewFile(
 	wg *sync.WaitGroup,
 	pool *ants.PoolWithFunc,
 	fileChan <-chan File,
+	readFiles map[string]File,
 	resultChan chan<- Result,
 	errorChan chan<- error,
 ) {
 	for file := range fileChan {
-		wg.Add(1)
-		if err := pool.Invoke(file); err != nil {
-			wg.Done()
-			errorChan <- taskSubmitErr(err, "reviewer")
-			continue
+		readFiles[file.Name] = file
+		for _, route := range routeFile(s.routes, &file) {
+			wg.Add(1)
+			if err := pool.Invoke(reviewTask{file: file, route: route}); err != nil {
+				wg.Done()
+				errorChan <- taskSubmitErr(err, "reviewer")
+				continue
+			}
 		}
 	}
 
@@ -186,6 +350,46 @@ func (s *service) reviewFile(
 	close(errorChan)
 }
 
+// reviewChangeset reviews the changed files as a whole, along with the content of the ones which were read.
+func (s *service) reviewChangeset(ctx context.Context, files []ChangedFile, readFiles map[string]File) Result {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msgf("reviewing changeset of %d files", len(files))
+
+	changeset := make([]reviewer.ChangesetFile, 0, len(files))
+	for _, file := range files {
+		changeset = append(changeset, reviewer.ChangesetFile{
+			Change: reviewer.Change{
+				Path:         file.Name,
+				Status:       file.Status,
+				PreviousPath: file.PreviousName,
+				Additions:    file.Additions,
+				Deletions:    file.Deletions,
+			},
+			Type:    readFiles[file.Name].Type,
+			Content: readFiles[file.Name].Content,
+		})
+	}
+
+	decision, err := s.changeset.Review(ctx, changeset)
+	if err != nil {
+		return Result{Route: ChangesetRoute, Error: fmt.Errorf("failed to review changeset: %w", err)}
+	}
+
+	return Result{Route: ChangesetRoute, Decision: decision}
+}
+
+// basePath returns the path of the file before the change, there is none for added files.
+func (f *File) basePath() (string, bool) {
+	switch {
+	case f.Status == statusAdded:
+		return "", false
+	case f.PreviousName != "":
+		return f.PreviousName, true
+	default:
+		return f.Name, true
+	}
+}
+
 func processFileErr(err error, process string) error {
 	return fmt.Errorf("failed to %s file: %w", process, err)
 }
@@ -195,13 +399,24 @@ func taskSubmitErr(err error, poolType string) error {
 }
 
 func New(
-	fileReviewer reviewer.Reviewer,
+	routes []Route,
 	readerPoolSize int,
 	reviewerPoolSize int,
+	opts ...ServiceOption,
 ) (Service, error) {
-	return &service{
+	if len(routes) == 0 {
+		return nil, errors.New("at least one route is required")
+	}
+
+	s := &service{
 		readerPoolSize:   readerPoolSize,
 		reviewerPoolSize: reviewerPoolSize,
-		fileReviewer:     fileReviewer,
-	}, nil
+		routes:           routes,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
 }
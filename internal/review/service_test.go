@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 
+	"github.com/CameronXie/go-opa-reviewer/pkg/reviewer"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
 )
@@ -15,36 +17,45 @@ func mockReadFileFun(_ context.Context, file string) ([]byte, error) {
 		return nil, errors.New("access denied")
 	}
 
+	if strings.Contains(file, "manifest") {
+		return []byte("apiVersion: v1\nkind: ConfigMap\n"), nil
+	}
+
 	return []byte(file), nil
 }
 
 type mockReviewer struct {
 }
 
-func (m *mockReviewer) Review(_ context.Context, content []byte) ([]byte, error) {
+func (m *mockReviewer) Review(_ context.Context, content []byte, _ ...reviewer.Option) (*reviewer.Decision, error) {
 	if strings.Contains(string(content), "invalid_review") {
 		return nil, errors.New("invalid")
 	}
 
-	return []byte("valid"), nil
+	if content == nil {
+		return &reviewer.Decision{Allow: false}, nil
+	}
+
+	return &reviewer.Decision{Allow: true}, nil
 }
 
 func TestService_Review(t *testing.T) {
 	cases := map[string]struct {
-		files           []string
+		files           []ChangedFile
 		expectedResults []Result
 		expectedErrMsg  *string
 	}{
 		"review files": {
-			files: []string{
-				"file_1",
-				"invalid_read_file_2",
-				"invalid_review_file_3",
+			files: []ChangedFile{
+				{Name: "file_1", Status: "added"},
+				{Name: "invalid_read_file_2", Status: "modified"},
+				{Name: "invalid_review_file_3", Status: "modified"},
 			},
 			expectedResults: []Result{
 				{
-					File:   "file_1",
-					Output: []byte("valid"),
+					File:     "file_1",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: true},
 				},
 				{
 					File:  "invalid_read_file_2",
@@ -52,16 +63,133 @@ func TestService_Review(t *testing.T) {
 				},
 				{
 					File:  "invalid_review_file_3",
+					Route: "default",
 					Error: fmt.Errorf("failed to review file: %w", errors.New("invalid")),
 				},
 			},
 		},
+		"review files with every matching route": {
+			files: []ChangedFile{
+				{Name: "stack/file_1.yaml", Status: "modified"},
+				{Name: "k8s/file_2.yaml", Status: "modified"},
+				{Name: "docs/file_3.md", Status: "modified"},
+			},
+			expectedResults: []Result{
+				{
+					File:     "stack/file_1.yaml",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+				{
+					File:     "stack/file_1.yaml",
+					Route:    "cfn",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+				{
+					File:     "k8s/file_2.yaml",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+			},
+		},
+		"review files with routes accepting their detected type": {
+			files: []ChangedFile{
+				{Name: "k8s/manifest.yaml", Status: "modified"},
+				{Name: "k8s/values.yaml", Status: "modified"},
+			},
+			expectedResults: []Result{
+				{
+					File:     "k8s/manifest.yaml",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+				{
+					File:     "k8s/manifest.yaml",
+					Route:    "k8s",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+				{
+					File:     "k8s/values.yaml",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+			},
+		},
+		"review removed files without reading them": {
+			files: []ChangedFile{
+				{Name: "invalid_read_file_1", Status: "removed", Deletions: 10},
+			},
+			expectedResults: []Result{
+				{
+					File:     "invalid_read_file_1",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: false},
+				},
+			},
+		},
+		"review removed files with every matching route when their type is unknown": {
+			files: []ChangedFile{
+				{Name: "k8s/file_1.yaml", Status: "removed"},
+			},
+			expectedResults: []Result{
+				{
+					File:     "k8s/file_1.yaml",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: false},
+				},
+				{
+					File:     "k8s/file_1.yaml",
+					Route:    "k8s",
+					Decision: &reviewer.Decision{Allow: false},
+				},
+			},
+		},
+		"skip files excluded by status": {
+			files: []ChangedFile{
+				{Name: "stack/file_1.yaml", Status: "removed"},
+				{Name: "stack/file_2.yaml", Status: "added"},
+			},
+			expectedResults: []Result{
+				{
+					File:     "stack/file_1.yaml",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: false},
+				},
+				{
+					File:     "stack/file_2.yaml",
+					Route:    "default",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+				{
+					File:     "stack/file_2.yaml",
+					Route:    "cfn",
+					Decision: &reviewer.Decision{Allow: true},
+				},
+			},
+		},
 	}
 
 	for n, tc := range cases {
 		t.Run(n, func(t *testing.T) {
 			a := assert.New(t)
-			svc, err := New(new(mockReviewer), 1, 1)
+			svc, err := New(
+				[]Route{
+					{
+						Name:     "default",
+						Patterns: []string{"file_*", "invalid_*", "**/*.yaml"},
+						Reviewer: new(mockReviewer),
+					},
+					{Name: "cfn", Patterns: []string{"stack/**/*.yaml", "!removed:**"}, Reviewer: new(mockReviewer)},
+					{
+						Name:     "k8s",
+						Patterns: []string{"k8s/**"},
+						Types:    []reviewer.FileType{reviewer.FileTypeKubernetes},
+						Reviewer: new(mockReviewer),
+					},
+				},
+				1,
+				1,
+			)
 			a.NoError(err)
 
 			results, err := svc.Review(context.TODO(), mockReadFileFun, tc.files)
@@ -75,3 +203,185 @@ func TestService_Review(t *testing.T) {
 		})
 	}
 }
+
+func TestService_Review_WithBase(t *testing.T) {
+	a := assert.New(t)
+	svc, err := New([]Route{{Name: "default", Patterns: []string{"**"}, Reviewer: new(mockReviewer)}}, 1, 1)
+	a.NoError(err)
+
+	var mu sync.Mutex
+	basePaths := make([]string, 0)
+	readBase := func(ctx context.Context, file string) ([]byte, error) {
+		mu.Lock()
+		basePaths = append(basePaths, file)
+		mu.Unlock()
+
+		return mockReadFileFun(ctx, file)
+	}
+
+	results, err := svc.Review(context.TODO(), mockReadFileFun, []ChangedFile{
+		{Name: "file_1", Status: "added"},
+		{Name: "file_2", Status: "modified"},
+		{Name: "file_3", Status: "renamed", PreviousName: "invalid_read_file_3"},
+		{Name: "file_4", Status: "removed"},
+	}, WithBase(readBase))
+
+	a.NoError(err)
+	a.ElementsMatch([]string{"file_2", "invalid_read_file_3", "file_4"}, basePaths)
+	a.ElementsMatch([]Result{
+		{File: "file_1", Route: "default", Decision: &reviewer.Decision{Allow: true}},
+		{File: "file_2", Route: "default", Decision: &reviewer.Decision{Allow: true}},
+		{File: "file_3", Error: fmt.Errorf("failed to read base file: %w", errors.New("access denied"))},
+		{File: "file_4", Route: "default", Decision: &reviewer.Decision{Allow: false}},
+	}, results)
+}
+
+func TestService_Review_RemovedFileWithBase(t *testing.T) {
+	a := assert.New(t)
+	svc, err := New([]Route{{
+		Name:     "k8s",
+		Patterns: []string{"k8s/**"},
+		Types:    []reviewer.FileType{reviewer.FileTypeKubernetes},
+		Reviewer: new(mockReviewer),
+	}}, 1, 1)
+	a.NoError(err)
+
+	results, err := svc.Review(context.TODO(), mockReadFileFun, []ChangedFile{
+		{Name: "k8s/manifest.yaml", Status: "removed"},
+		{Name: "k8s/values.yaml", Status: "removed"},
+	}, WithBase(mockReadFileFun))
+
+	a.NoError(err)
+	a.Equal([]Result{{File: "k8s/manifest.yaml", Route: "k8s", Decision: &reviewer.Decision{Allow: false}}}, results)
+}
+
+func TestService_Review_WithEnvelope(t *testing.T) {
+	a := assert.New(t)
+	prodReviewer, err := reviewer.NewReviewerWithBundle(
+		context.TODO(),
+		"data.reviewer.prod",
+		"../../pkg/reviewer/testdata/bundle.tar.gz",
+	)
+	a.NoError(err)
+
+	svc, err := New([]Route{
+		{Name: "envelope", Patterns: []string{"**"}, Envelope: true, Reviewer: prodReviewer},
+		{Name: "legacy", Patterns: []string{"**"}, Reviewer: prodReviewer},
+	}, 1, 1)
+	a.NoError(err)
+
+	results, err := svc.Review(
+		context.TODO(),
+		mockReadFileFun,
+		[]ChangedFile{{Name: "stack/prod/app.yaml", Status: "modified"}},
+		WithEnvelope(reviewer.Envelope{Repository: "owner/repo", BaseRef: "develop"}),
+	)
+	a.NoError(err)
+	a.Len(results, 2)
+
+	decisions := make(map[string]*reviewer.Decision)
+	for idx := range results {
+		decisions[results[idx].Route] = results[idx].Decision
+	}
+
+	a.False(decisions["envelope"].Allow)
+	a.Equal("prod.base_ref", decisions["envelope"].Violations[0].RuleID)
+	a.True(decisions["legacy"].Allow)
+	a.Empty(decisions["legacy"].Violations)
+}
+
+type mockChangesetReviewer struct {
+	files []reviewer.ChangesetFile
+	err   error
+}
+
+func (m *mockChangesetReviewer) Review(_ context.Context, files []reviewer.ChangesetFile) (*reviewer.Decision, error) {
+	m.files = files
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	return &reviewer.Decision{Allow: len(files) < 3}, nil
+}
+
+func TestService_Review_WithChangesetReviewer(t *testing.T) {
+	manifest := reviewer.ChangesetFile{
+		Change:  reviewer.Change{Path: "k8s/manifest.yaml", Status: "added"},
+		Type:    reviewer.FileTypeKubernetes,
+		Content: []byte("apiVersion: v1\nkind: ConfigMap\n"),
+	}
+	removed := reviewer.ChangesetFile{Change: reviewer.Change{Path: "file_2", Status: "removed", Deletions: 3}}
+
+	cases := map[string]struct {
+		opts           []Option
+		changesetErr   error
+		expectedFiles  []reviewer.ChangesetFile
+		expectedResult Result
+	}{
+		"review reviewed files as changeset": {
+			expectedFiles:  []reviewer.ChangesetFile{manifest, removed},
+			expectedResult: Result{Route: ChangesetRoute, Decision: &reviewer.Decision{Allow: true}},
+		},
+		"review all changed files as changeset": {
+			opts: []Option{WithChangedFiles([]ChangedFile{
+				{Name: "k8s/manifest.yaml", Status: "added"},
+				{Name: "file_2", Status: "removed", Deletions: 3},
+				{Name: "CHANGELOG.md", Status: "modified", Additions: 1},
+			})},
+			expectedFiles: []reviewer.ChangesetFile{
+				manifest,
+				removed,
+				{Change: reviewer.Change{Path: "CHANGELOG.md", Status: "modified", Additions: 1}},
+			},
+			expectedResult: Result{Route: ChangesetRoute, Decision: &reviewer.Decision{Allow: false}},
+		},
+		"skip changeset": {
+			opts: []Option{WithoutChangeset()},
+		},
+		"failed to review changeset should return error result": {
+			changesetErr:  errors.New("invalid"),
+			expectedFiles: []reviewer.ChangesetFile{manifest, removed},
+			expectedResult: Result{
+				Route: ChangesetRoute,
+				Error: fmt.Errorf("failed to review changeset: %w", errors.New("invalid")),
+			},
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			changeset := &mockChangesetReviewer{err: tc.changesetErr}
+			svc, err := New(
+				[]Route{{Name: "default", Patterns: []string{"**"}, Reviewer: new(mockReviewer)}},
+				1,
+				1,
+				WithChangesetReviewer(changeset),
+			)
+			a.NoError(err)
+
+			results, err := svc.Review(context.TODO(), mockReadFileFun, []ChangedFile{
+				{Name: "k8s/manifest.yaml", Status: "added"},
+				{Name: "file_2", Status: "removed", Deletions: 3},
+			}, tc.opts...)
+
+			a.NoError(err)
+			a.Equal(tc.expectedFiles, changeset.files)
+			if tc.expectedFiles == nil {
+				a.Len(results, 2)
+				return
+			}
+
+			a.Len(results, 3)
+			a.Equal(tc.expectedResult, results[2])
+			a.False(results[2].IsFile())
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	a := assert.New(t)
+	svc, err := New(nil, 1, 1)
+	a.Nil(svc)
+	a.EqualError(err, "at least one route is required")
+}
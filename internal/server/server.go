@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	HealthRoute            = "/healthz"
+	ReadyRoute             = "/readyz"
+	defaultShutdownTimeout = 30 * time.Second
+	readHeaderTimeout      = 10 * time.Second
+)
+
+// Server serves the webhook handler over HTTP, or HTTPS when a certificate is configured, along with the liveness and
+// readiness endpoints of orchestrators such as Kubernetes.
+type Server struct {
+	httpServer      *http.Server
+	certFile        string
+	keyFile         string
+	shutdownTimeout time.Duration
+	ready           atomic.Bool
+}
+
+// Option configures the optional behaviours of the server.
+type Option func(*Server)
+
+// WithTLS serves HTTPS with the certificate and private key files, accepting TLS 1.2 and later.
+func WithTLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+// WithShutdownTimeout bounds how long the in-flight requests, e.g. the reviews of webhook deliveries, are waited for
+// once the server is shutting down.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// Run listens on the address of the server and serves until ctx is done, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves the requests accepted by the listener until ctx is done. The server is then reported as not ready,
+// stops accepting requests and waits for the in-flight ones until the shutdown timeout.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	logger := zerolog.Ctx(ctx)
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.certFile != "" {
+			serveErr <- s.httpServer.ServeTLS(listener, s.certFile, s.keyFile)
+			return
+		}
+
+		serveErr <- s.httpServer.Serve(listener)
+	}()
+
+	s.ready.Store(true)
+	logger.Info().Msgf("serving on %s", listener.Addr())
+
+	select {
+	case err := <-serveErr:
+		s.ready.Store(false)
+		return err
+	case <-ctx.Done():
+	}
+
+	s.ready.Store(false)
+	logger.Info().Msg("shutting down, waiting for in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.shutdownTimeout)
+	defer cancel()
+
+	shutdownErr := s.httpServer.Shutdown(shutdownCtx)
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return errors.Join(err, shutdownErr)
+	}
+
+	return shutdownErr
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleReady reports the server as ready once it serves requests, and as unavailable once it is shutting down, so
+// no more deliveries are routed to it.
+func (s *Server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if !s.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// New returns a server serving the handler on the address, except for the health and readiness routes.
+func New(addr string, handler http.Handler, opts ...Option) *Server {
+	s := &Server{shutdownTimeout: defaultShutdownTimeout}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthRoute, s.handleHealth)
+	mux.HandleFunc(ReadyRoute, s.handleReady)
+	mux.Handle("/", handler)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: readHeaderTimeout,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+	}
+
+	return s
+}
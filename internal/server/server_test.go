@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Routes(t *testing.T) {
+	cases := map[string]struct {
+		route          string
+		ready          bool
+		expectedStatus int
+	}{
+		"report health": {
+			route:          HealthRoute,
+			expectedStatus: http.StatusOK,
+		},
+		"report ready while serving": {
+			route:          ReadyRoute,
+			ready:          true,
+			expectedStatus: http.StatusOK,
+		},
+		"report not ready otherwise": {
+			route:          ReadyRoute,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		"forward other routes to handler": {
+			route:          "/api/github/hook",
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			s := New(":0", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			s.ready.Store(tc.ready)
+
+			recorder := httptest.NewRecorder()
+			s.httpServer.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.route, http.NoBody))
+
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+		})
+	}
+}
+
+func TestServer_Serve_DrainsInFlightRequests(t *testing.T) {
+	a := assert.New(t)
+	started, release := make(chan struct{}), make(chan struct{})
+	s := New(":0", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+	}), WithShutdownTimeout(5*time.Second))
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(ctx, listener)
+	}()
+
+	responded := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(fmt.Sprintf("http://%s/api/github/hook", listener.Addr()), "application/json", http.NoBody)
+		if err != nil {
+			responded <- 0
+			return
+		}
+
+		defer resp.Body.Close()
+		responded <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	// The server is reported as not ready as soon as the shutdown starts, while the request is still in flight.
+	a.Eventually(func() bool { return !s.ready.Load() }, time.Second, 10*time.Millisecond)
+	close(release)
+
+	a.Equal(http.StatusAccepted, <-responded)
+	a.Nil(<-served)
+}
+
+func TestServer_Run_ListenError(t *testing.T) {
+	err := New("invalid address", http.NotFoundHandler()).Run(context.Background())
+	assert.Error(t, err)
+}
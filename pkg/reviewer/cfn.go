@@ -0,0 +1,70 @@
+package reviewer
+
+import (
+	"strings"
+)
+
+const getAttTag = "!GetAtt"
+
+// cfnFunctionTags are the CloudFormation short-form tags converted into their long-form Fn:: equivalents.
+var cfnFunctionTags = map[string]bool{
+	"!And":          true,
+	"!Base64":       true,
+	"!Cidr":         true,
+	"!Equals":       true,
+	"!FindInMap":    true,
+	"!GetAZs":       true,
+	"!GetAtt":       true,
+	"!If":           true,
+	"!ImportValue":  true,
+	"!Join":         true,
+	"!Length":       true,
+	"!Not":          true,
+	"!Or":           true,
+	"!Select":       true,
+	"!Split":        true,
+	"!Sub":          true,
+	"!ToJsonString": true,
+	"!Transform":    true,
+}
+
+// cfnKeywordTags are the CloudFormation short-form tags which are not prefixed with Fn:: in their long-form.
+var cfnKeywordTags = map[string]bool{
+	"!Ref":       true,
+	"!Condition": true,
+}
+
+// isCfnTag checks if the tag is a CloudFormation short-form tag.
+func isCfnTag(tag string) bool {
+	return cfnKeywordTags[tag] || cfnFunctionTags[tag]
+}
+
+// cfnKey returns the long-form key of a CloudFormation short-form tag, e.g. Ref for !Ref and Fn::Sub for !Sub.
+func cfnKey(tag string) (string, bool) {
+	switch {
+	case cfnKeywordTags[tag]:
+		return strings.TrimPrefix(tag, "!"), true
+	case cfnFunctionTags[tag]:
+		return "Fn::" + strings.TrimPrefix(tag, "!"), true
+	default:
+		return "", false
+	}
+}
+
+// cfnIntrinsic converts the value of a node tagged with a CloudFormation short-form tag (e.g. !Ref, !Sub, !GetAtt)
+// into its long-form JSON equivalent (e.g. {"Ref": ...}, {"Fn::Sub": ...}, {"Fn::GetAtt": [...]}).
+// It reports false if the tag is not a CloudFormation intrinsic function.
+func cfnIntrinsic(tag string, value any) (any, bool) {
+	key, ok := cfnKey(tag)
+	if !ok {
+		return nil, false
+	}
+
+	// The short-form of GetAtt is a logicalNameOfResource.attributeName string.
+	if str, isStr := value.(string); isStr && tag == getAttTag {
+		resource, attribute, _ := strings.Cut(str, ".")
+		value = []any{resource, attribute}
+	}
+
+	return map[string]any{key: value}, true
+}
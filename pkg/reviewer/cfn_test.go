@@ -0,0 +1,118 @@
+package reviewer
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocuments_CloudFormationShortFormTags(t *testing.T) {
+	a := assert.New(t)
+	content, readErr := os.ReadFile("testdata/template.yaml")
+	a.NoError(readErr)
+
+	documents, err := parseDocuments(content)
+	a.NoError(err)
+	a.Equal([]document{{index: 0, value: map[string]any{
+		"AWSTemplateFormatVersion": "2010-09-09",
+		"Description":              "Short-form intrinsic functions.",
+		"Conditions": map[string]any{
+			"IsProduction": map[string]any{"Fn::Equals": []any{map[string]any{"Ref": "Environment"}, "production"}},
+		},
+		"Resources": map[string]any{
+			"Subnet": map[string]any{
+				"Type": "AWS::EC2::Subnet",
+				"Properties": map[string]any{
+					"AvailabilityZone": map[string]any{"Fn::Select": []any{0, map[string]any{"Fn::GetAZs": ""}}},
+					"VpcId":            map[string]any{"Ref": "VPC"},
+					"CidrBlock":        map[string]any{"Fn::FindInMap": []any{"SubnetConfig", "Public", "CIDR"}},
+					"Tags": []any{
+						map[string]any{"Key": "Name", "Value": map[string]any{"Fn::Sub": "${AWS::StackName}-subnet"}},
+					},
+				},
+			},
+			"SecurityGroup": map[string]any{
+				"Type":      "AWS::EC2::SecurityGroup",
+				"Condition": "IsProduction",
+				"Properties": map[string]any{
+					"GroupDescription": map[string]any{"Fn::Join": []any{" ", []any{"Allow", "HTTP"}}},
+					"VpcId":            map[string]any{"Fn::GetAtt": []any{"Subnet", "VpcId"}},
+					"SecurityGroupIngress": []any{
+						map[string]any{
+							"IpProtocol": "tcp",
+							"FromPort":   80,
+							"ToPort":     80,
+							"CidrIp":     map[string]any{"Fn::If": []any{"IsProduction", "10.0.0.0/25", "0.0.0.0/0"}},
+						},
+						map[string]any{
+							"IpProtocol": "tcp",
+							"FromPort":   443,
+							"ToPort":     443,
+							"CidrIp":     "0.0.0.0/0",
+						},
+					},
+				},
+			},
+		},
+		"Outputs": map[string]any{
+			"SecurityGroupId": map[string]any{
+				"Value": map[string]any{"Fn::GetAtt": []any{"SecurityGroup", "GroupId"}},
+				"Export": map[string]any{
+					"Name": map[string]any{"Fn::Sub": []any{"${Stack}-sg", map[string]any{"Stack": map[string]any{"Ref": "AWS::StackName"}}}},
+				},
+			},
+		},
+	}}}, withoutSourceMaps(documents))
+}
+
+func TestCfnIntrinsic(t *testing.T) {
+	cases := map[string]struct {
+		tag      string
+		value    any
+		expected any
+		ok       bool
+	}{
+		"ref": {
+			tag:      "!Ref",
+			value:    "VPC",
+			expected: map[string]any{"Ref": "VPC"},
+			ok:       true,
+		},
+		"condition": {
+			tag:      "!Condition",
+			value:    "IsProduction",
+			expected: map[string]any{"Condition": "IsProduction"},
+			ok:       true,
+		},
+		"get attribute with nested attribute name": {
+			tag:      "!GetAtt",
+			value:    "Database.Endpoint.Address",
+			expected: map[string]any{"Fn::GetAtt": []any{"Database", "Endpoint.Address"}},
+			ok:       true,
+		},
+		"base64": {
+			tag:      "!Base64",
+			value:    "script",
+			expected: map[string]any{"Fn::Base64": "script"},
+			ok:       true,
+		},
+		"unknown tag": {
+			tag:   "!Unknown",
+			value: "value",
+		},
+		"standard tag": {
+			tag:   "!!str",
+			value: "value",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			actual, ok := cfnIntrinsic(tc.tag, tc.value)
+			a.Equal(tc.expected, actual)
+			a.Equal(tc.ok, ok)
+		})
+	}
+}
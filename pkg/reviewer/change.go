@@ -0,0 +1,95 @@
+package reviewer
+
+const (
+	changeKey = "change"
+
+	// ChangeStatusRemoved is the status of a file removed by a change, which is reviewed without content.
+	ChangeStatusRemoved = "removed"
+)
+
+// Change is the change made to the reviewed file, e.g. by a pull request. It is given to the policies of the object
+// documents under the change key, so they can review the change itself, e.g. forbid removing protected stacks.
+type Change struct {
+	Path         string
+	Status       string
+	PreviousPath string
+	Additions    int
+	Deletions    int
+}
+
+// Option configures a single review.
+type Option func(*options)
+
+type options struct {
+	change   *Change
+	diff     bool
+	base     []byte
+	envelope *Envelope
+}
+
+// WithChange reviews the content as the given change of a file. The content of removed files is ignored, and a single
+// empty document is reviewed instead.
+func WithChange(change Change) Option {
+	return func(o *options) {
+		o.change = &change
+	}
+}
+
+// WithBase reviews the content as a change of the base content, e.g. the file on the base branch of a pull request,
+// which is nil if the file did not exist. The violations are classified as new or unchanged against the ones of the
+// base content, and the object documents are given their base document under the base key.
+func WithBase(content []byte) Option {
+	return func(o *options) {
+		o.diff = true
+		o.base = content
+	}
+}
+
+// removed reports whether the file is reviewed as removed.
+func (o *options) removed() bool {
+	return o.change != nil && o.change.Status == ChangeStatusRemoved
+}
+
+// extra returns the extra input values of a document, which are the change of the file and the base document.
+func (o *options) extra(base any) map[string]any {
+	extra := make(map[string]any)
+	if o.change != nil {
+		extra[changeKey] = o.change.value()
+	}
+
+	if base != nil {
+		extra[baseKey] = base
+	}
+
+	return extra
+}
+
+// input returns the policy input of a document given its extra values, wrapped in the envelope if any.
+func (o *options) input(doc *document, extra map[string]any) any {
+	if o.envelope == nil {
+		return doc.input(extra)
+	}
+
+	var path string
+	if o.change != nil {
+		path = o.change.Path
+	}
+
+	return o.envelope.wrap(doc, path, extra)
+}
+
+// value returns the policy input of the change.
+func (c *Change) value() map[string]any {
+	value := map[string]any{
+		"path":      c.Path,
+		"status":    c.Status,
+		"additions": c.Additions,
+		"deletions": c.Deletions,
+	}
+
+	if c.PreviousPath != "" {
+		value["previous_path"] = c.PreviousPath
+	}
+
+	return value
+}
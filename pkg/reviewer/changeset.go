@@ -0,0 +1,72 @@
+package reviewer
+
+import (
+	"fmt"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"golang.org/x/net/context"
+)
+
+const changesetFilesKey = "files"
+
+// ChangesetFile is a file of a changeset, along with its content, which is nil if the file was removed or not read.
+type ChangesetFile struct {
+	Change
+	Type    FileType
+	Content []byte
+}
+
+// ChangesetReviewer reviews a changeset as a whole, e.g. all the files changed by a pull request.
+type ChangesetReviewer interface {
+	Review(ctx context.Context, files []ChangesetFile) (*Decision, error)
+}
+
+type changesetReviewer struct {
+	reviewer *reviewer
+}
+
+// Review evaluates a single input listing the files of the changeset, see NewChangesetReviewer.
+func (r *changesetReviewer) Review(ctx context.Context, files []ChangesetFile) (*Decision, error) {
+	inputFiles := make([]any, 0, len(files))
+	for idx := range files {
+		inputFiles = append(inputFiles, files[idx].value())
+	}
+
+	decision, err := r.reviewer.evaluateInput(ctx, map[string]any{changesetFilesKey: inputFiles})
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate changeset: %w", err)
+	}
+
+	return decision, nil
+}
+
+// value returns the policy input of the file, which is its change, its detected type and its parsed documents.
+// The documents of a content which cannot be parsed are left out.
+func (f *ChangesetFile) value() map[string]any {
+	value := f.Change.value()
+	value["type"] = string(f.Type)
+
+	documents := make([]any, 0)
+	if parsed, err := parseDocuments(f.Content); err == nil && len(f.Content) > 0 {
+		for idx := range parsed {
+			documents = append(documents, parsed[idx].value)
+		}
+	}
+
+	value["documents"] = documents
+	return value
+}
+
+// NewChangesetReviewer initializes a ChangesetReviewer with a query prepared against a loaded bundle, following the
+// same conventions as NewReviewer. The policies are given a single input listing the files of the changeset:
+//
+//	{"files": [{"path": "stack/app.yaml", "status": "modified", "additions": 1, "deletions": 1,
+//	            "type": "cloudformation", "documents": [{"Resources": {}}]}]}
+func NewChangesetReviewer(ctx context.Context, queryStr string, b *bundle.Bundle) (ChangesetReviewer, error) {
+	r, err := newReviewer(ctx, queryStr, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &changesetReviewer{reviewer: r}, nil
+}
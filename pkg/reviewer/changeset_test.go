@@ -0,0 +1,69 @@
+package reviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangesetReviewer_Review(t *testing.T) {
+	a := assert.New(t)
+	b, err := LoadBundle("testdata/bundle.tar.gz")
+	a.NoError(err)
+
+	r, err := NewChangesetReviewer(context.TODO(), "data.reviewer.changeset", b)
+	a.NoError(err)
+
+	decision, reviewErr := r.Review(context.TODO(), []ChangesetFile{
+		{
+			Change:  Change{Path: "stack/prod/app.yaml", Status: "modified", Additions: 1},
+			Type:    FileTypeCloudFormation,
+			Content: []byte("Resources: {}"),
+		},
+		{Change: Change{Path: "stack/prod/db.yaml", Status: ChangeStatusRemoved}, Type: FileTypeCloudFormation},
+	})
+	a.NoError(reviewErr)
+	a.Equal(&Decision{
+		Allow: false,
+		Violations: []Violation{
+			{
+				RuleID:   "changeset.changelog",
+				Severity: SeverityError,
+				Message:  "changes to stack/prod/app.yaml, stack/prod/db.yaml require a CHANGELOG.md entry",
+				Package:  "reviewer.changeset",
+			},
+			{
+				RuleID:   "changeset.single_stack",
+				Severity: SeverityError,
+				Message:  "only one stack may be changed at a time, found stack/prod/app.yaml, stack/prod/db.yaml",
+				Package:  "reviewer.changeset",
+			},
+		},
+		Documents: 1,
+	}, decision)
+
+	_, queryErr := NewChangesetReviewer(context.TODO(), "data.reviewer[", b)
+	a.ErrorContains(queryErr, "failed to parse the opa policy query")
+}
+
+func TestChangesetFile_Value(t *testing.T) {
+	a := assert.New(t)
+	file := &ChangesetFile{
+		Change:  Change{Path: "k8s/app.yaml", Status: "added", Additions: 4},
+		Type:    FileTypeKubernetes,
+		Content: []byte("kind: Secret\n---\nkind: ConfigMap\n"),
+	}
+
+	a.Equal(map[string]any{
+		"path":      "k8s/app.yaml",
+		"status":    "added",
+		"additions": 4,
+		"deletions": 0,
+		"type":      "kubernetes",
+		"documents": []any{map[string]any{"kind": "Secret"}, map[string]any{"kind": "ConfigMap"}},
+	}, file.value())
+
+	invalid := &ChangesetFile{Change: Change{Path: "README.md", Status: "modified"}, Content: []byte("'invalid")}
+	a.Equal([]any{}, invalid.value()["documents"])
+}
@@ -0,0 +1,199 @@
+package reviewer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+)
+
+// Decision is the structured outcome of reviewing a file against the policies.
+//
+// Every policy package is expected to evaluate to an object which reports violations through either of:
+//
+//   - conftest style deny, warn and info rule sets (or rules prefixed with deny_, warn_ and info_), whose
+//     elements are either a message string or a violation object. The severity is decided by the rule name.
+//   - a violations set of violation objects, where the severity defaults to error.
+//
+// A violation object has the following shape:
+//
+//	{
+//	  "rule": "cfn.security_group.open_ingress",
+//	  "severity": "error",
+//	  "msg": "security group SecurityGroupA allows ingress from 0.0.0.0/0",
+//	  "resource": "SecurityGroupA",
+//	  "path": "Resources.SecurityGroupA.Properties.SecurityGroupIngress[0].CidrIp",
+//	  "metadata": {},
+//	  "fix": {"patch": [{"op": "replace", "path": "/Resources/SecurityGroupA/...", "value": "10.0.0.0/8"}]}
+//	}
+//
+// The path of the offending node is optional, and may be a JSON pointer, a dotted path or an array of keys.
+// The fix is optional as well, see Fix.
+//
+// A package may also define an allow flag, otherwise the file is allowed if no error level violations are returned.
+// Documents is the number of documents reviewed in the file, which is greater than one for multi-document YAML.
+// Fixed holds the violations of the base content which are no longer found, when the file is reviewed against a base.
+type Decision struct {
+	Allow      bool        `json:"allow"`
+	Violations []Violation `json:"violations"`
+	Fixed      []Violation `json:"fixed,omitempty"`
+	Documents  int         `json:"documents"`
+}
+
+// Violation is a single finding reported by a policy rule.
+// The Package of the rule, the Document and Location of the offending node, the Suggestion built from the Fix, and
+// the Change against the base content, are recorded by the Reviewer.
+type Violation struct {
+	RuleID     string          `json:"rule"`
+	Severity   Severity        `json:"severity"`
+	Message    string          `json:"msg"`
+	ResourceID string          `json:"resource,omitempty"`
+	Path       Path            `json:"path,omitempty"`
+	Metadata   map[string]any  `json:"metadata,omitempty"`
+	Package    string          `json:"package"`
+	Fix        *Fix            `json:"fix,omitempty"`
+	Document   int             `json:"document"`
+	Location   Location        `json:"location"`
+	Suggestion *Suggestion     `json:"suggestion,omitempty"`
+	Change     ViolationChange `json:"change,omitempty"`
+}
+
+const (
+	allowKey      = "allow"
+	violationsKey = "violations"
+)
+
+// HighestSeverity returns the highest severity among the violations, or an empty Severity if there are none.
+func (d *Decision) HighestSeverity() Severity {
+	var severity Severity
+	for idx := range d.Violations {
+		severity = severity.Max(d.Violations[idx].Severity)
+	}
+
+	return severity
+}
+
+// decodeDecision converts the result set of a policy query into a Decision.
+// The query is expected to evaluate to an array holding the document of every package in packages.
+func decodeDecision(results rego.ResultSet, packages []ast.Ref) (*Decision, error) {
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return nil, errors.New("policy query returned no result")
+	}
+
+	documents, ok := results[0].Expressions[0].Value.([]any)
+	if !ok || len(documents) != len(packages) {
+		return nil, errors.New("failed to decode decision: unexpected query result")
+	}
+
+	decision := &Decision{
+		Allow:      true,
+		Violations: make([]Violation, 0),
+	}
+
+	for idx := range documents {
+		pkgDecision, err := decodePackageDocument(packageName(packages[idx]), documents[idx])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode decision: %w", err)
+		}
+
+		for vIdx := range pkgDecision.Violations {
+			pkgDecision.Violations[vIdx].Package = packageName(packages[idx])
+		}
+
+		decision.Allow = decision.Allow && pkgDecision.Allow
+		decision.Violations = append(decision.Violations, pkgDecision.Violations...)
+	}
+
+	return decision, nil
+}
+
+// decodePackageDocument converts the document of a single policy package into a Decision.
+func decodePackageDocument(pkg string, document any) (*Decision, error) {
+	value, marshalErr := json.Marshal(document)
+	if marshalErr != nil {
+		return nil, marshalErr
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(value, &fields); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	decision := &Decision{Violations: make([]Violation, 0)}
+	for _, name := range names {
+		violations, err := decodeRule(pkg, name, fields[name])
+		if err != nil {
+			return nil, err
+		}
+
+		decision.Violations = append(decision.Violations, violations...)
+	}
+
+	decision.Allow = decision.HighestSeverity() != SeverityError
+	if raw, ok := fields[allowKey]; ok {
+		if err := json.Unmarshal(raw, &decision.Allow); err != nil {
+			return nil, err
+		}
+	}
+
+	return decision, nil
+}
+
+// decodeRule converts the value of a rule following the violations, deny, warn or info conventions into violations.
+// Values of other rules are ignored.
+func decodeRule(pkg, name string, raw json.RawMessage) ([]Violation, error) {
+	if name == violationsKey {
+		violations := make([]Violation, 0)
+		if err := json.Unmarshal(raw, &violations); err != nil {
+			return nil, err
+		}
+
+		for idx := range violations {
+			if violations[idx].Severity == "" {
+				violations[idx].Severity = SeverityError
+			}
+		}
+
+		return violations, nil
+	}
+
+	severity, ok := ruleSeverity(name)
+	if !ok {
+		return nil, nil
+	}
+
+	elements := make([]json.RawMessage, 0)
+	if err := json.Unmarshal(raw, &elements); err != nil {
+		return nil, fmt.Errorf("rule %s must be a set: %w", name, err)
+	}
+
+	violations := make([]Violation, 0, len(elements))
+	for _, element := range elements {
+		violation := Violation{RuleID: fmt.Sprintf("%s.%s", pkg, name)}
+		if err := json.Unmarshal(element, &violation.Message); err != nil {
+			if objErr := json.Unmarshal(element, &violation); objErr != nil {
+				return nil, fmt.Errorf("rule %s must contain messages or violation objects: %w", name, objErr)
+			}
+		}
+
+		violation.Severity = severity
+		violations = append(violations, violation)
+	}
+
+	return violations, nil
+}
+
+// packageName returns the package path without the leading data document.
+func packageName(pkg ast.Ref) string {
+	return strings.TrimPrefix(pkg.String(), "data.")
+}
@@ -0,0 +1,106 @@
+package reviewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodePackageDocument(t *testing.T) {
+	cases := map[string]struct {
+		document any
+		expected *Decision
+		errMsg   *string
+	}{
+		"decode violations with default severity": {
+			document: map[string]any{
+				"allow": false,
+				"violations": []any{
+					map[string]any{"rule": "rule_1", "msg": "violation_1", "resource": "resource_1"},
+					map[string]any{"rule": "rule_2", "msg": "violation_2", "severity": "warning"},
+				},
+			},
+			expected: &Decision{
+				Allow: false,
+				Violations: []Violation{
+					{RuleID: "rule_1", Severity: SeverityError, Message: "violation_1", ResourceID: "resource_1"},
+					{RuleID: "rule_2", Severity: SeverityWarning, Message: "violation_2"},
+				},
+			},
+		},
+		"decode deny, warn and info rules": {
+			document: map[string]any{
+				"deny_open_ingress": []any{"violation_1"},
+				"warn":              []any{map[string]any{"rule": "rule_2", "msg": "violation_2", "severity": "info"}},
+				"info":              []any{},
+				"helper":            true,
+			},
+			expected: &Decision{
+				Allow: false,
+				Violations: []Violation{
+					{RuleID: "pkg.deny_open_ingress", Severity: SeverityError, Message: "violation_1"},
+					{RuleID: "rule_2", Severity: SeverityWarning, Message: "violation_2"},
+				},
+			},
+		},
+		"allow when only warnings found": {
+			document: map[string]any{
+				"warn": []any{"violation_1"},
+			},
+			expected: &Decision{
+				Allow:      true,
+				Violations: []Violation{{RuleID: "pkg.warn", Severity: SeverityWarning, Message: "violation_1"}},
+			},
+		},
+		"explicit allow takes precedence": {
+			document: map[string]any{
+				"allow": true,
+				"deny":  []any{"violation_1"},
+			},
+			expected: &Decision{
+				Allow:      true,
+				Violations: []Violation{{RuleID: "pkg.deny", Severity: SeverityError, Message: "violation_1"}},
+			},
+		},
+		"rule which is not a set should return error": {
+			document: map[string]any{
+				"deny": true,
+			},
+			errMsg: strPtr("rule deny must be a set"),
+		},
+		"rule with invalid elements should return error": {
+			document: map[string]any{
+				"deny": []any{1},
+			},
+			errMsg: strPtr("rule deny must contain messages or violation objects"),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			decision, err := decodePackageDocument("pkg", tc.document)
+
+			if tc.errMsg != nil {
+				a.ErrorContains(err, *tc.errMsg)
+				return
+			}
+
+			a.NoError(err)
+			a.Equal(tc.expected, decision)
+		})
+	}
+}
+
+func TestDecision_HighestSeverity(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(Severity(""), (&Decision{}).HighestSeverity())
+	a.Equal(SeverityWarning, (&Decision{Violations: []Violation{
+		{Severity: SeverityInfo},
+		{Severity: SeverityWarning},
+	}}).HighestSeverity())
+}
+
+func strPtr(str string) *string {
+	return &str
+}
@@ -0,0 +1,54 @@
+package reviewer
+
+import (
+	"fmt"
+)
+
+// ViolationChange classifies a violation against the violations of the base content of a file.
+type ViolationChange string
+
+const (
+	ViolationNew       ViolationChange = "new"
+	ViolationUnchanged ViolationChange = "unchanged"
+	ViolationFixed     ViolationChange = "fixed"
+)
+
+const baseKey = "base"
+
+// classify marks the violations of the decision as new, or unchanged when the base decision has the same violation,
+// and records the violations of the base decision which are no longer found as fixed.
+// Violations are compared by their package, rule, resource, path and message, as their locations may have moved.
+func classify(decision, base *Decision) {
+	remaining := make(map[string]int)
+	for idx := range base.Violations {
+		remaining[base.Violations[idx].identity()]++
+	}
+
+	for idx := range decision.Violations {
+		identity := decision.Violations[idx].identity()
+		decision.Violations[idx].Change = ViolationNew
+		if remaining[identity] > 0 {
+			decision.Violations[idx].Change = ViolationUnchanged
+			remaining[identity]--
+		}
+	}
+
+	decision.Fixed = make([]Violation, 0)
+	for idx := range base.Violations {
+		identity := base.Violations[idx].identity()
+		if remaining[identity] == 0 {
+			continue
+		}
+
+		remaining[identity]--
+		fixed := base.Violations[idx]
+		fixed.Change = ViolationFixed
+		fixed.Suggestion = nil
+		decision.Fixed = append(decision.Fixed, fixed)
+	}
+}
+
+// identity identifies a violation regardless of its location in the file.
+func (v *Violation) identity() string {
+	return fmt.Sprintf("%q %q %q %q %q", v.Package, v.RuleID, v.ResourceID, v.Path, v.Message)
+}
@@ -0,0 +1,29 @@
+package reviewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	a := assert.New(t)
+	openIngress := Violation{RuleID: "open_ingress", Package: "reviewer.cfn", ResourceID: "SG", Message: "open"}
+	noDescription := Violation{RuleID: "no_description", Package: "reviewer.cfn", Message: "no description"}
+	latestTag := Violation{RuleID: "latest_tag", Package: "reviewer.k8s", ResourceID: "Deployment/app"}
+
+	moved := openIngress
+	moved.Location = Location{StartLine: 20}
+
+	decision := &Decision{Violations: []Violation{moved, latestTag, latestTag}}
+	classify(decision, &Decision{Violations: []Violation{openIngress, noDescription, latestTag}})
+
+	a.Equal([]ViolationChange{ViolationUnchanged, ViolationUnchanged, ViolationNew}, []ViolationChange{
+		decision.Violations[0].Change,
+		decision.Violations[1].Change,
+		decision.Violations[2].Change,
+	})
+
+	noDescription.Change = ViolationFixed
+	a.Equal([]Violation{noDescription}, decision.Fixed)
+}
@@ -0,0 +1,282 @@
+package reviewer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	documentIndexKey = "document_index"
+	cfnResourcesKey  = "Resources"
+	mergeTag         = "!!merge"
+	// minAliasedNodes and maxAliasRatio bound the expansion of aliases, e.g. by a billion laughs document. A document
+	// may expand more aliased nodes than maxAliasRatio of all its converted nodes only while it is small.
+	minAliasedNodes = 10000
+	maxAliasRatio   = 0.9
+)
+
+var errAliasExpansion = errors.New("document expands too many aliases")
+
+// document is a single YAML or JSON document parsed from a file, along with the source map of its nodes.
+type document struct {
+	index     int
+	value     any
+	sourceMap sourceMap
+}
+
+// input returns the policy input of the document. Object documents are given the index of the document in the file
+// under the document_index key, the detected type of the document, if any, under the file_type key, and the extra
+// values of the review, e.g. the change of the file under the change key.
+func (d *document) input(extra map[string]any) any {
+	obj, ok := d.value.(map[string]any)
+	if !ok {
+		return d.value
+	}
+
+	input := make(map[string]any, len(obj)+len(extra)+2)
+	for key, value := range obj {
+		input[key] = value
+	}
+
+	input[documentIndexKey] = d.index
+	if fileType := d.fileType(); fileType != FileTypeUnknown {
+		input[fileTypeKey] = string(fileType)
+	}
+
+	for key, value := range extra {
+		input[key] = value
+	}
+
+	return input
+}
+
+// locate returns the location of the node a violation refers to. It falls back to the resource of the violation
+// for CloudFormation templates, and to the whole document when neither is found.
+func (d *document) locate(violation *Violation) Location {
+	if violation.Path != "" {
+		if location, ok := d.sourceMap.locate(string(violation.Path)); ok {
+			return location
+		}
+	}
+
+	if violation.ResourceID != "" {
+		if entry, ok := d.sourceMap[pointer(cfnResourcesKey, violation.ResourceID)]; ok {
+			return entry.location
+		}
+	}
+
+	return d.sourceMap[""].location
+}
+
+// removedDocument is the single empty document reviewed for a removed file.
+func removedDocument() document {
+	return document{value: make(map[string]any), sourceMap: make(sourceMap)}
+}
+
+// parseDocuments splits the content into its YAML documents (a JSON file is a single document) and converts each of
+// them into a JSON compatible value. Empty documents are skipped.
+func parseDocuments(content []byte) ([]document, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	lines := strings.Split(string(content), "\n")
+	documents := make([]document, 0)
+
+	for idx := 0; ; idx++ {
+		node := new(yaml.Node)
+		if err := decoder.Decode(node); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, err
+		}
+
+		value, err := new(converter).nodeValue(node)
+		if err != nil {
+			return nil, err
+		}
+
+		if value == nil {
+			continue
+		}
+
+		documents = append(documents, document{index: idx, value: value, sourceMap: newSourceMap(node.Content[0], lines)})
+	}
+
+	if len(documents) == 0 {
+		return nil, errors.New("failed to parse input")
+	}
+
+	return documents, nil
+}
+
+// converter converts the nodes of a YAML document into JSON compatible values, counting the nodes it converts, and
+// the ones expanded from aliases, to stop documents whose aliases expand exponentially.
+type converter struct {
+	nodes      int
+	aliased    int
+	aliasDepth int
+}
+
+// nodeValue converts a YAML node into a JSON compatible value.
+// Nodes tagged with CloudFormation short-form tags are converted into their long-form equivalents.
+func (c *converter) nodeValue(node *yaml.Node) (any, error) {
+	if err := c.count(); err != nil {
+		return nil, err
+	}
+
+	value, err := c.kindValue(node)
+	if err != nil {
+		return nil, err
+	}
+
+	if intrinsic, ok := cfnIntrinsic(node.Tag, value); ok {
+		return intrinsic, nil
+	}
+
+	return value, nil
+}
+
+// count counts a converted node, and fails once the nodes expanded from aliases exceed the allowed ratio.
+func (c *converter) count() error {
+	c.nodes++
+	if c.aliasDepth == 0 {
+		return nil
+	}
+
+	c.aliased++
+	if c.aliased > minAliasedNodes && float64(c.aliased) > maxAliasRatio*float64(c.nodes) {
+		return errAliasExpansion
+	}
+
+	return nil
+}
+
+// kindValue converts a YAML node into a JSON compatible value according to its kind.
+func (c *converter) kindValue(node *yaml.Node) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return c.nodeValue(node.Content[0])
+	case yaml.SequenceNode:
+		return c.sequenceValue(node)
+	case yaml.MappingNode:
+		return c.mappingValue(node)
+	case yaml.AliasNode:
+		c.aliasDepth++
+		defer func() { c.aliasDepth-- }()
+
+		return c.nodeValue(node.Alias)
+	case yaml.ScalarNode:
+		return scalarValue(node)
+	default:
+		return nil, fmt.Errorf("unsupported yaml node kind %d at line %d", node.Kind, node.Line)
+	}
+}
+
+func (c *converter) sequenceValue(node *yaml.Node) ([]any, error) {
+	values := make([]any, 0, len(node.Content))
+	for _, item := range node.Content {
+		value, err := c.nodeValue(item)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// mappingValue converts a mapping node into an object, the explicit keys take precedence over the merged ones.
+func (c *converter) mappingValue(node *yaml.Node) (map[string]any, error) {
+	values := make(map[string]any, len(node.Content)/2)
+	merged := make(map[string]any)
+
+	for idx := 0; idx+1 < len(node.Content); idx += 2 {
+		key, item := node.Content[idx], node.Content[idx+1]
+		value, err := c.nodeValue(item)
+		if err != nil {
+			return nil, err
+		}
+
+		if key.ShortTag() != mergeTag {
+			values[key.Value] = value
+			continue
+		}
+
+		if err := mergeValue(merged, value); err != nil {
+			return nil, fmt.Errorf("%w at line %d", err, key.Line)
+		}
+	}
+
+	for key, value := range merged {
+		if _, ok := values[key]; !ok {
+			values[key] = value
+		}
+	}
+
+	return values, nil
+}
+
+// mergeValue merges a mapping, or a sequence of mappings, referenced by a merge key into dst.
+// Mappings earlier in a sequence take precedence over the later ones.
+func mergeValue(dst map[string]any, value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if _, ok := dst[key]; !ok {
+				dst[key] = item
+			}
+		}
+	case []any:
+		for _, item := range v {
+			obj, ok := item.(map[string]any)
+			if !ok {
+				return errors.New("merge key requires mappings")
+			}
+
+			if err := mergeValue(dst, obj); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("merge key requires mappings")
+	}
+
+	return nil
+}
+
+// scalarValue resolves a scalar node into a JSON compatible value. Scalars tagged with CloudFormation short-form tags
+// are always strings, other local tags are resolved by the scalar content and timestamps are kept as strings.
+func scalarValue(node *yaml.Node) (any, error) {
+	if isCfnTag(node.Tag) {
+		return node.Value, nil
+	}
+
+	resolved := *node
+	if len(resolved.Tag) > 0 && resolved.Tag[0] == '!' && (len(resolved.Tag) == 1 || resolved.Tag[1] != '!') {
+		resolved.Tag = ""
+	}
+
+	switch resolved.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!str", "!!timestamp", "!!binary":
+		return resolved.Value, nil
+	}
+
+	var value any
+	if err := resolved.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
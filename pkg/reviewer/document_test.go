@@ -0,0 +1,147 @@
+package reviewer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocuments(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected []document
+		errMsg   *string
+	}{
+		"parse json document": {
+			content: `{"Resources": {"Bucket": {"Type": "AWS::S3::Bucket"}}, "Count": 2}`,
+			expected: []document{
+				{index: 0, value: map[string]any{
+					"Resources": map[string]any{"Bucket": map[string]any{"Type": "AWS::S3::Bucket"}},
+					"Count":     2,
+				}},
+			},
+		},
+		"parse multi-document yaml": {
+			content: `
+kind: ConfigMap
+---
+---
+kind: Secret
+data:
+  enabled: true
+  ratio: 0.5
+  empty: null
+`,
+			expected: []document{
+				{index: 0, value: map[string]any{"kind": "ConfigMap"}},
+				{index: 2, value: map[string]any{
+					"kind": "Secret",
+					"data": map[string]any{"enabled": true, "ratio": 0.5, "empty": nil},
+				}},
+			},
+		},
+		"parse yaml with anchors, merge keys and timestamps": {
+			content: `
+AWSTemplateFormatVersion: 2010-09-09
+Defaults: &defaults
+  Type: AWS::S3::Bucket
+  Retain: true
+Bucket:
+  <<: *defaults
+  Retain: false
+Tags:
+  - *defaults
+`,
+			expected: []document{
+				{index: 0, value: map[string]any{
+					"AWSTemplateFormatVersion": "2010-09-09",
+					"Defaults":                 map[string]any{"Type": "AWS::S3::Bucket", "Retain": true},
+					"Bucket":                   map[string]any{"Type": "AWS::S3::Bucket", "Retain": false},
+					"Tags":                     []any{map[string]any{"Type": "AWS::S3::Bucket", "Retain": true}},
+				}},
+			},
+		},
+		"parse yaml with local tags": {
+			content: "Enabled: !Custom true\nName: !Custom name\n",
+			expected: []document{
+				{index: 0, value: map[string]any{"Enabled": true, "Name": "name"}},
+			},
+		},
+		"parse empty content should return error": {
+			content: "---\n",
+			errMsg:  strPtr("failed to parse input"),
+		},
+		"parse invalid merge key should return error": {
+			content: "Bucket:\n  <<: value\n",
+			errMsg:  strPtr("merge key requires mappings at line 2"),
+		},
+		"parse yaml with exponential aliases should return error": {
+			content: aliasBomb(7),
+			errMsg:  strPtr("document expands too many aliases"),
+		},
+		"parse invalid yaml should return error": {
+			content: `'invalid_yaml`,
+			errMsg:  strPtr("found unexpected end of stream"),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			documents, err := parseDocuments([]byte(tc.content))
+
+			if tc.errMsg != nil {
+				a.ErrorContains(err, *tc.errMsg)
+				return
+			}
+
+			a.NoError(err)
+			a.Equal(tc.expected, withoutSourceMaps(documents))
+		})
+	}
+}
+
+func TestDocument_Input(t *testing.T) {
+	a := assert.New(t)
+	doc := &document{index: 2, value: map[string]any{"kind": "Secret"}}
+	a.Equal(map[string]any{"kind": "Secret", "document_index": 2}, doc.input(nil))
+	a.Equal(map[string]any{"kind": "Secret"}, doc.value)
+	a.Equal([]any{"item"}, (&document{index: 1, value: []any{"item"}}).input(nil))
+
+	manifest := &document{value: map[string]any{"apiVersion": "v1", "kind": "Secret"}}
+	a.Equal(map[string]any{"apiVersion": "v1", "kind": "Secret", "document_index": 0, "file_type": "kubernetes"},
+		manifest.input(nil))
+
+	change := &Change{Path: "stack/app.yaml", Status: "renamed", PreviousPath: "stack/old.yaml", Additions: 1}
+	a.Equal(map[string]any{
+		"document_index": 0,
+		"change": map[string]any{
+			"path":          "stack/app.yaml",
+			"status":        "renamed",
+			"previous_path": "stack/old.yaml",
+			"additions":     1,
+			"deletions":     0,
+		},
+	}, (&document{value: map[string]any{}}).input(map[string]any{changeKey: change.value()}))
+}
+
+func withoutSourceMaps(documents []document) []document {
+	for idx := range documents {
+		documents[idx].sourceMap = nil
+	}
+
+	return documents
+}
+
+// aliasBomb returns a billion laughs document, whose every level is a sequence of ten aliases of the previous one.
+func aliasBomb(levels int) string {
+	lines := []string{"l0: &l0 [lol]"}
+	for level := 1; level <= levels; level++ {
+		aliases := strings.TrimSuffix(strings.Repeat(fmt.Sprintf("*l%d, ", level-1), 10), ", ")
+		lines = append(lines, fmt.Sprintf("l%d: &l%d [%s]", level, level, aliases))
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
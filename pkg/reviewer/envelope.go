@@ -0,0 +1,55 @@
+package reviewer
+
+const (
+	documentKey = "document"
+	fileKey     = "file"
+)
+
+// Envelope is the context of the reviewed file, e.g. the pull request changing it, which the policies cannot tell from
+// the document alone.
+type Envelope struct {
+	Repository  string
+	BaseRef     string
+	HeadSHA     string
+	PullRequest int
+	Author      string
+}
+
+// WithEnvelope wraps every document of the content in an envelope holding the context of the file. The document is
+// given under the document key instead of being the input itself, along with the document index, the path and
+// detected type of the file, the change and the base document, e.g.
+//
+//	{"document": {"Resources": {}}, "document_index": 0, "file": {"path": "stack/app.yaml", "type": "cloudformation"},
+//	 "repository": "owner/repo", "base_ref": "main", "head_sha": "abc123",
+//	 "pull_request": {"number": 1, "author": "octocat"}, "change": {"status": "modified"}, "base": {"Resources": {}}}
+func WithEnvelope(envelope Envelope) Option {
+	return func(o *options) {
+		o.envelope = &envelope
+	}
+}
+
+// wrap returns the policy input of a document of the file at the given path wrapped in the envelope, along with the
+// extra values of the review.
+func (e *Envelope) wrap(doc *document, path string, extra map[string]any) map[string]any {
+	input := map[string]any{
+		documentKey:      doc.value,
+		documentIndexKey: doc.index,
+		fileKey: map[string]any{
+			"path": path,
+			"type": string(doc.fileType()),
+		},
+		"repository": e.Repository,
+		"base_ref":   e.BaseRef,
+		"head_sha":   e.HeadSHA,
+		pullRequestKey: map[string]any{
+			"number": e.PullRequest,
+			"author": e.Author,
+		},
+	}
+
+	for key, value := range extra {
+		input[key] = value
+	}
+
+	return input
+}
@@ -0,0 +1,91 @@
+package reviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReview_Envelope(t *testing.T) {
+	a := assert.New(t)
+	r, err := NewReviewerWithBundle(context.TODO(), "data.reviewer.prod", "testdata/bundle.tar.gz")
+	a.NoError(err)
+
+	content := []byte(`Resources:
+  Table:
+    Type: AWS::DynamoDB::Table
+`)
+
+	envelope := WithEnvelope(Envelope{
+		Repository:  "owner/repo",
+		BaseRef:     "develop",
+		HeadSHA:     "12345",
+		PullRequest: 1,
+		Author:      "octocat",
+	})
+
+	decision, reviewErr := r.Review(
+		context.TODO(),
+		content,
+		WithChange(Change{Path: "stack/prod/app.yaml", Status: "modified"}),
+		WithBase(content),
+		envelope,
+	)
+	a.NoError(reviewErr)
+	a.Equal(&Decision{
+		Allow: false,
+		Violations: []Violation{
+			{
+				RuleID:     "prod.deletion_policy",
+				Severity:   SeverityError,
+				Message:    "production resource Table must retain its data",
+				Package:    "reviewer.prod",
+				ResourceID: "Table",
+				Path:       "/Resources/Table",
+				Location:   Location{StartLine: 2, StartColumn: 3, EndLine: 3, EndColumn: 30},
+				Change:     ViolationUnchanged,
+			},
+			{
+				RuleID:   "prod.base_ref",
+				Severity: SeverityError,
+				Message:  "production stack stack/prod/app.yaml must be merged into main, found develop",
+				Package:  "reviewer.prod",
+				Location: Location{StartLine: 1, StartColumn: 1, EndLine: 3, EndColumn: 30},
+				Change:   ViolationUnchanged,
+			},
+		},
+		Fixed:     []Violation{},
+		Documents: 1,
+	}, decision)
+
+	legacy, legacyErr := r.Review(
+		context.TODO(),
+		content,
+		WithChange(Change{Path: "stack/prod/app.yaml", Status: "modified"}),
+	)
+	a.NoError(legacyErr)
+	a.Empty(legacy.Violations)
+}
+
+func TestEnvelope_Wrap(t *testing.T) {
+	envelope := &Envelope{
+		Repository:  "owner/repo",
+		BaseRef:     "main",
+		HeadSHA:     "12345",
+		PullRequest: 1,
+		Author:      "octocat",
+	}
+	doc := &document{index: 2, value: map[string]any{"apiVersion": "v1", "kind": "ConfigMap"}}
+
+	assert.Equal(t, map[string]any{
+		"document":       map[string]any{"apiVersion": "v1", "kind": "ConfigMap"},
+		"document_index": 2,
+		"file":           map[string]any{"path": "k8s/app.yaml", "type": "kubernetes"},
+		"repository":     "owner/repo",
+		"base_ref":       "main",
+		"head_sha":       "12345",
+		"pull_request":   map[string]any{"number": 1, "author": "octocat"},
+		"change":         map[string]any{"status": "added"},
+	}, envelope.wrap(doc, "k8s/app.yaml", map[string]any{"change": map[string]any{"status": "added"}}))
+}
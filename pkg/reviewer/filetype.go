@@ -0,0 +1,99 @@
+package reviewer
+
+import (
+	"strings"
+)
+
+// FileType is the kind of configuration a document holds, detected from its content rather than its file name.
+type FileType string
+
+const (
+	FileTypeUnknown        FileType = ""
+	FileTypeCloudFormation FileType = "cloudformation"
+	FileTypeKubernetes     FileType = "kubernetes"
+	FileTypeGitHubWorkflow FileType = "github_workflow"
+	FileTypeTerraformPlan  FileType = "terraform_plan"
+)
+
+const fileTypeKey = "file_type"
+
+// FileTypes are the file types which can be detected.
+var FileTypes = []FileType{FileTypeCloudFormation, FileTypeKubernetes, FileTypeGitHubWorkflow, FileTypeTerraformPlan}
+
+// DetectFileType returns the type of the first document of the content whose type is detected, or FileTypeUnknown
+// if there is none or the content cannot be parsed.
+func DetectFileType(content []byte) FileType {
+	documents, err := parseDocuments(content)
+	if err != nil {
+		return FileTypeUnknown
+	}
+
+	for idx := range documents {
+		if fileType := documents[idx].fileType(); fileType != FileTypeUnknown {
+			return fileType
+		}
+	}
+
+	return FileTypeUnknown
+}
+
+// fileType detects the type of the document from the signature of its top level keys.
+func (d *document) fileType() FileType {
+	obj, ok := d.value.(map[string]any)
+	if !ok {
+		return FileTypeUnknown
+	}
+
+	switch {
+	case hasKeys(obj, "AWSTemplateFormatVersion") || hasCfnResources(obj):
+		return FileTypeCloudFormation
+	case isString(obj["apiVersion"]) && isString(obj["kind"]):
+		return FileTypeKubernetes
+	case hasKeys(obj, "on") && isObject(obj["jobs"]):
+		return FileTypeGitHubWorkflow
+	case hasKeys(obj, "format_version", "terraform_version") &&
+		(hasKeys(obj, "planned_values") || hasKeys(obj, "resource_changes")):
+		return FileTypeTerraformPlan
+	default:
+		return FileTypeUnknown
+	}
+}
+
+// hasCfnResources reports whether the Resources of the document has any resource of a CloudFormation resource type,
+// e.g. AWS::S3::Bucket or Custom::Resource.
+func hasCfnResources(obj map[string]any) bool {
+	resources, ok := obj[cfnResourcesKey].(map[string]any)
+	if !ok {
+		return false
+	}
+
+	for _, resource := range resources {
+		if props, ok := resource.(map[string]any); ok {
+			if resourceType, ok := props["Type"].(string); ok && strings.Contains(resourceType, "::") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hasKeys(obj map[string]any, keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := obj[key]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isString(value any) bool {
+	_, ok := value.(string)
+	return ok
+}
+
+func isObject(value any) bool {
+	_, ok := value.(map[string]any)
+	return ok
+}
@@ -0,0 +1,54 @@
+package reviewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFileType(t *testing.T) {
+	cases := map[string]struct {
+		content  string
+		expected FileType
+	}{
+		"detect cloudformation template by version": {
+			content:  "AWSTemplateFormatVersion: '2010-09-09'\nResources: {}\n",
+			expected: FileTypeCloudFormation,
+		},
+		"detect cloudformation template by resources": {
+			content:  "Resources:\n  Bucket:\n    Type: AWS::S3::Bucket\n    Properties:\n      BucketName: !Ref Name\n",
+			expected: FileTypeCloudFormation,
+		},
+		"detect kubernetes manifest": {
+			content:  "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: app\n",
+			expected: FileTypeKubernetes,
+		},
+		"detect kubernetes manifest from later document": {
+			content:  "---\nname: values\n---\napiVersion: v1\nkind: ConfigMap\n",
+			expected: FileTypeKubernetes,
+		},
+		"detect github workflow": {
+			content:  "name: ci\non:\n  push:\njobs:\n  test:\n    runs-on: ubuntu-latest\n",
+			expected: FileTypeGitHubWorkflow,
+		},
+		"detect terraform plan": {
+			content:  `{"format_version": "1.2", "terraform_version": "1.6.0", "resource_changes": []}`,
+			expected: FileTypeTerraformPlan,
+		},
+		"not detect resources without resource types": {
+			content: "Resources:\n  - name: app\n",
+		},
+		"not detect unknown document": {
+			content: "name: app\nversion: 1.0.0\n",
+		},
+		"not detect invalid content": {
+			content: "'invalid",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, DetectFileType([]byte(tc.content)))
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package reviewer
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const patchOpReplace = "replace"
+
+// Fix is a machine-readable remediation of a violation, returned by a policy under the fix key of a violation object.
+// It is either a JSON Patch against the input document, of which only replace operations of single line scalar values
+// are supported, or a replacement of whole lines, which are the lines of the offending node unless given explicitly.
+//
+//	{"patch": [{"op": "replace", "path": "/spec/containers/0/securityContext/privileged", "value": false}]}
+//	{"replacement": "    privileged: false", "start_line": 12, "end_line": 12}
+type Fix struct {
+	Patch       []PatchOperation `json:"patch,omitempty"`
+	Replacement *string          `json:"replacement,omitempty"`
+	StartLine   int              `json:"start_line,omitempty"`
+	EndLine     int              `json:"end_line,omitempty"`
+}
+
+// PatchOperation is a single JSON Patch operation, the path accepts the same formats as the path of a violation.
+type PatchOperation struct {
+	Op    string `json:"op"`
+	Path  Path   `json:"path"`
+	Value any    `json:"value"`
+}
+
+// Suggestion is the content replacing a range of lines of the reviewed file, built from the Fix of a violation.
+type Suggestion struct {
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Content   string `json:"content"`
+}
+
+// lineEdit replaces the characters of a line between two zero based columns, the end column being exclusive.
+type lineEdit struct {
+	line        int
+	startColumn int
+	endColumn   int
+	text        string
+}
+
+// suggest builds the suggestion of a violation from its fix and the lines of the file. No suggestion is returned if
+// the fix cannot be applied while preserving the formatting of the file.
+func (d *document) suggest(violation *Violation, lines []string) *Suggestion {
+	fix := violation.Fix
+	if fix == nil {
+		return nil
+	}
+
+	if fix.Replacement != nil {
+		start, end := violation.Location.StartLine, violation.Location.EndLine
+		if fix.StartLine > 0 {
+			start, end = fix.StartLine, max(fix.EndLine, fix.StartLine)
+		}
+
+		if start < 1 || end < start || end > len(lines) {
+			return nil
+		}
+
+		return &Suggestion{StartLine: start, EndLine: end, Content: *fix.Replacement}
+	}
+
+	if len(fix.Patch) == 0 {
+		return nil
+	}
+
+	edits := make([]lineEdit, 0, len(fix.Patch))
+	for idx := range fix.Patch {
+		edit, ok := d.patchEdit(&fix.Patch[idx], lines)
+		if !ok {
+			return nil
+		}
+
+		edits = append(edits, edit)
+	}
+
+	return applyEdits(edits, lines)
+}
+
+// patchEdit converts a replace operation of a single line scalar value into a line edit. The edit is rejected unless
+// the text it replaces is exactly the scalar, e.g. when the columns of the scalar are not known precisely.
+func (d *document) patchEdit(op *PatchOperation, lines []string) (lineEdit, bool) {
+	entry, ok := d.sourceMap[string(op.Path)]
+	if !ok || op.Op != patchOpReplace {
+		return lineEdit{}, false
+	}
+
+	node := entry.node
+	if node.Kind != yaml.ScalarNode || node.Style&(yaml.TaggedStyle|yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return lineEdit{}, false
+	}
+
+	endLine, endColumn := entry.location.EndLine, entry.location.EndColumn
+	if endLine != node.Line || endColumn == 0 || node.Line > len(lines) {
+		return lineEdit{}, false
+	}
+
+	line := []rune(strings.TrimSuffix(lines[node.Line-1], "\r"))
+	if endColumn > len(line) || !isScalarText(string(line[node.Column-1:endColumn]), node) {
+		return lineEdit{}, false
+	}
+
+	text, ok := scalarText(op.Value, node.Style)
+	if !ok {
+		return lineEdit{}, false
+	}
+
+	return lineEdit{line: node.Line, startColumn: node.Column - 1, endColumn: endColumn, text: text}, true
+}
+
+// isScalarText reports whether the text is the source of the scalar node, which is the value itself for a plain
+// scalar, and the quoted text decoding into the value for a quoted one.
+func isScalarText(text string, node *yaml.Node) bool {
+	if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) == 0 {
+		return text == node.Value
+	}
+
+	var decoded yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &decoded); err != nil || len(decoded.Content) != 1 {
+		return false
+	}
+
+	scalar := decoded.Content[0]
+	return scalar.Kind == yaml.ScalarNode && scalar.Style == node.Style && scalar.Value == node.Value
+}
+
+// applyEdits applies the edits to the lines they change, and returns the changed range of lines as a suggestion.
+// Overlapping edits are rejected.
+func applyEdits(edits []lineEdit, lines []string) *Suggestion {
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line < edits[j].line
+		}
+
+		return edits[i].startColumn > edits[j].startColumn
+	})
+
+	start, end := edits[0].line, edits[len(edits)-1].line
+	changed := make([][]rune, 0, end-start+1)
+	for _, line := range lines[start-1 : end] {
+		changed = append(changed, []rune(strings.TrimSuffix(line, "\r")))
+	}
+
+	for idx, edit := range edits {
+		if idx > 0 && edits[idx-1].line == edit.line && edits[idx-1].startColumn < edit.endColumn {
+			return nil
+		}
+
+		line := changed[edit.line-start]
+		if edit.endColumn > len(line) {
+			return nil
+		}
+
+		changed[edit.line-start] = append(append([]rune(string(line[:edit.startColumn])), []rune(edit.text)...),
+			line[edit.endColumn:]...)
+	}
+
+	content := make([]string, 0, len(changed))
+	for _, line := range changed {
+		content = append(content, string(line))
+	}
+
+	return &Suggestion{StartLine: start, EndLine: end, Content: strings.Join(content, "\n")}
+}
+
+// scalarText renders a JSON value as a single line YAML scalar, keeping the quoting style of the replaced string.
+func scalarText(value any, style yaml.Style) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		if strings.ContainsAny(v, "\r\n") {
+			return "", false
+		}
+
+		switch {
+		case style&yaml.DoubleQuotedStyle != 0:
+			var buf bytes.Buffer
+			encoder := json.NewEncoder(&buf)
+			encoder.SetEscapeHTML(false)
+			if err := encoder.Encode(v); err != nil {
+				return "", false
+			}
+
+			return strings.TrimSuffix(buf.String(), "\n"), true
+		case style&yaml.SingleQuotedStyle != 0:
+			return "'" + strings.ReplaceAll(v, "'", "''") + "'", true
+		}
+	case nil, bool, float64:
+	default:
+		return "", false
+	}
+
+	out, err := yaml.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+
+	text := strings.TrimSuffix(string(out), "\n")
+	return text, !strings.Contains(text, "\n")
+}
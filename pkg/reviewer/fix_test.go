@@ -0,0 +1,122 @@
+package reviewer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument_Suggest(t *testing.T) {
+	content := `spec:
+  containers:
+    - name: app
+      image: "app:latest"
+      securityContext:
+        privileged: true
+      args: ['--mode', 'debug']
+      role: !Ref Role
+      command: "run\t\"app\""
+      workingDir: 'é/it''s'
+`
+
+	cases := map[string]struct {
+		violation Violation
+		expected  *Suggestion
+	}{
+		"no fix": {
+			violation: Violation{},
+		},
+		"replace plain value": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "replace", Path: "/spec/containers/0/securityContext/privileged", Value: false},
+			}}},
+			expected: &Suggestion{StartLine: 6, EndLine: 6, Content: "        privileged: false"},
+		},
+		"replace plain value with a string requiring quotes": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "replace", Path: "/spec/containers/0/securityContext/privileged", Value: "true"},
+			}}},
+			expected: &Suggestion{StartLine: 6, EndLine: 6, Content: `        privileged: "true"`},
+		},
+		"replace values and keep quoting style": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "replace", Path: "/spec/containers/0/args/1", Value: "it's"},
+				{Op: "replace", Path: "/spec/containers/0/image", Value: "app:1.0.0"},
+				{Op: "replace", Path: "/spec/containers/0/args/0", Value: "--level"},
+			}}},
+			expected: &Suggestion{
+				StartLine: 4,
+				EndLine:   7,
+				Content:   "      image: \"app:1.0.0\"\n      securityContext:\n        privileged: true\n      args: ['--level', 'it''s']", // nolint: lll
+			},
+		},
+		"replace escaped and multi-byte quoted values": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "replace", Path: "/spec/containers/0/command", Value: "z"},
+				{Op: "replace", Path: "/spec/containers/0/workingDir", Value: "/app"},
+			}}},
+			expected: &Suggestion{StartLine: 9, EndLine: 10, Content: "      command: \"z\"\n      workingDir: '/app'"},
+		},
+		"unsupported operation": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "remove", Path: "/spec/containers/0/securityContext/privileged"},
+			}}},
+		},
+		"unknown path": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "replace", Path: "/spec/volumes", Value: "volume"},
+			}}},
+		},
+		"tagged value": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "replace", Path: "/spec/containers/0/role", Value: "role"},
+			}}},
+		},
+		"non scalar value": {
+			violation: Violation{Fix: &Fix{Patch: []PatchOperation{
+				{Op: "replace", Path: "/spec/containers/0/image", Value: []any{"app"}},
+			}}},
+		},
+		"replace lines of the offending node": {
+			violation: Violation{
+				Location: Location{StartLine: 5, EndLine: 6},
+				Fix:      &Fix{Replacement: strPtr("      securityContext: {}")},
+			},
+			expected: &Suggestion{StartLine: 5, EndLine: 6, Content: "      securityContext: {}"},
+		},
+		"replace given lines": {
+			violation: Violation{
+				Location: Location{StartLine: 5, EndLine: 6},
+				Fix:      &Fix{Replacement: strPtr(""), StartLine: 3},
+			},
+			expected: &Suggestion{StartLine: 3, EndLine: 3, Content: ""},
+		},
+		"replace lines out of range": {
+			violation: Violation{Fix: &Fix{Replacement: strPtr(""), StartLine: 20}},
+		},
+	}
+
+	documents, err := parseDocuments([]byte(content))
+	assert.NoError(t, err)
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, documents[0].suggest(&tc.violation, strings.Split(content, "\n")))
+		})
+	}
+}
+
+func TestDocument_Suggest_RejectsInexactColumns(t *testing.T) {
+	content := "key: \"x\\ty\"\n"
+	documents, err := parseDocuments([]byte(content))
+	assert.NoError(t, err)
+
+	// The end column of the unescaped value, which stops before the closing quote of the source.
+	entry := documents[0].sourceMap["/key"]
+	entry.location.EndColumn = entry.node.Column + len(entry.node.Value) + 1
+	documents[0].sourceMap["/key"] = entry
+
+	violation := Violation{Fix: &Fix{Patch: []PatchOperation{{Op: "replace", Path: "/key", Value: "z"}}}}
+	assert.Nil(t, documents[0].suggest(&violation, strings.Split(content, "\n")))
+}
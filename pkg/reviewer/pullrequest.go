@@ -0,0 +1,93 @@
+package reviewer
+
+import (
+	"fmt"
+
+	"github.com/open-policy-agent/opa/bundle"
+	"golang.org/x/net/context"
+)
+
+const pullRequestKey = "pull_request"
+
+// PullRequest is the metadata of a pull request, reviewed on its own rather than through its changed files, e.g. to
+// require a linked issue or forbid fixup commits.
+type PullRequest struct {
+	Number            int
+	Title             string
+	Body              string
+	Labels            []string
+	BaseRef           string
+	HeadRef           string
+	Author            string
+	AuthorAssociation string
+	Draft             bool
+	LinkedIssues      []int
+	CommitMessages    []string
+}
+
+// PullRequestReviewer reviews the metadata of a pull request.
+type PullRequestReviewer interface {
+	Review(ctx context.Context, pr *PullRequest) (*Decision, error)
+}
+
+type pullRequestReviewer struct {
+	reviewer *reviewer
+}
+
+// Review evaluates a single input holding the pull request, see NewPullRequestReviewer.
+func (r *pullRequestReviewer) Review(ctx context.Context, pr *PullRequest) (*Decision, error) {
+	decision, err := r.reviewer.evaluateInput(ctx, map[string]any{pullRequestKey: pr.value()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate pull request: %w", err)
+	}
+
+	return decision, nil
+}
+
+// value returns the policy input of the pull request.
+func (pr *PullRequest) value() map[string]any {
+	labels := make([]any, 0, len(pr.Labels))
+	for _, label := range pr.Labels {
+		labels = append(labels, label)
+	}
+
+	issues := make([]any, 0, len(pr.LinkedIssues))
+	for _, issue := range pr.LinkedIssues {
+		issues = append(issues, issue)
+	}
+
+	messages := make([]any, 0, len(pr.CommitMessages))
+	for _, message := range pr.CommitMessages {
+		messages = append(messages, message)
+	}
+
+	return map[string]any{
+		"number":             pr.Number,
+		"title":              pr.Title,
+		"body":               pr.Body,
+		"labels":             labels,
+		"base_ref":           pr.BaseRef,
+		"head_ref":           pr.HeadRef,
+		"author":             pr.Author,
+		"author_association": pr.AuthorAssociation,
+		"draft":              pr.Draft,
+		"linked_issues":      issues,
+		"commit_messages":    messages,
+	}
+}
+
+// NewPullRequestReviewer initializes a PullRequestReviewer with a query prepared against a loaded bundle, following
+// the same conventions as NewReviewer. The policies are given a single input holding the pull request:
+//
+//	{"pull_request": {"number": 1, "title": "feat: add stack", "body": "Closes #2", "labels": ["infra"],
+//	                  "base_ref": "main", "head_ref": "feat/stack", "author": "octocat",
+//	                  "author_association": "MEMBER", "draft": false, "linked_issues": [2],
+//	                  "commit_messages": ["feat: add stack"]}}
+func NewPullRequestReviewer(ctx context.Context, queryStr string, b *bundle.Bundle) (PullRequestReviewer, error) {
+	r, err := newReviewer(ctx, queryStr, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pullRequestReviewer{reviewer: r}, nil
+}
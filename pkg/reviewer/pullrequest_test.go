@@ -0,0 +1,82 @@
+package reviewer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullRequestReviewer_Review(t *testing.T) {
+	a := assert.New(t)
+	b, err := LoadBundle("testdata/bundle.tar.gz")
+	a.NoError(err)
+
+	r, err := NewPullRequestReviewer(context.TODO(), "data.reviewer.pull_request", b)
+	a.NoError(err)
+
+	decision, reviewErr := r.Review(context.TODO(), &PullRequest{
+		Number:         1,
+		Title:          "Add queue",
+		Labels:         []string{"infra"},
+		CommitMessages: []string{"feat: add queue", "fixup! feat: add queue"},
+	})
+	a.NoError(reviewErr)
+	a.Equal(&Decision{
+		Allow: false,
+		Violations: []Violation{
+			{
+				RuleID:   "pull_request.linked_issue",
+				Severity: SeverityError,
+				Message:  "pull request must link an issue, or be labeled no-issue",
+				Package:  "reviewer.pull_request",
+			},
+			{
+				RuleID:   "pull_request.title",
+				Severity: SeverityError,
+				Message:  "title \"Add queue\" does not follow the conventional commits format",
+				Package:  "reviewer.pull_request",
+			},
+			{
+				RuleID:   "pull_request.fixup_commit",
+				Severity: SeverityWarning,
+				Message:  "commit \"fixup! feat: add queue\" should be squashed before merging",
+				Package:  "reviewer.pull_request",
+			},
+		},
+		Documents: 1,
+	}, decision)
+
+	_, queryErr := NewPullRequestReviewer(context.TODO(), "data.reviewer[", b)
+	a.ErrorContains(queryErr, "failed to parse the opa policy query")
+}
+
+func TestPullRequest_Value(t *testing.T) {
+	pr := &PullRequest{
+		Number:            1,
+		Title:             "feat: add queue",
+		Body:              "Closes #2",
+		Labels:            []string{"infra"},
+		BaseRef:           "main",
+		HeadRef:           "feat/queue",
+		Author:            "octocat",
+		AuthorAssociation: "MEMBER",
+		Draft:             true,
+		LinkedIssues:      []int{2},
+		CommitMessages:    []string{"feat: add queue"},
+	}
+
+	assert.Equal(t, map[string]any{
+		"number":             1,
+		"title":              "feat: add queue",
+		"body":               "Closes #2",
+		"labels":             []any{"infra"},
+		"base_ref":           "main",
+		"head_ref":           "feat/queue",
+		"author":             "octocat",
+		"author_association": "MEMBER",
+		"draft":              true,
+		"linked_issues":      []any{2},
+		"commit_messages":    []any{"feat: add queue"},
+	}, pr.value())
+}
@@ -1,41 +1,131 @@
 package reviewer
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/bundle"
+	"github.com/open-policy-agent/opa/loader"
 	"github.com/open-policy-agent/opa/rego"
-	"github.com/open-policy-agent/opa/util"
 	"golang.org/x/net/context"
 )
 
 type Reviewer interface {
-	Review(ctx context.Context, content []byte) ([]byte, error)
+	Review(ctx context.Context, content []byte, opts ...Option) (*Decision, error)
 }
 
 type reviewer struct {
-	query rego.PreparedEvalQuery
+	query    rego.PreparedEvalQuery
+	packages []ast.Ref
 }
 
-// Review evaluates a given content using a prepared query and returns the results in JSON format.
-func (r *reviewer) Review(ctx context.Context, content []byte) ([]byte, error) {
-	var input any
-	if err := util.Unmarshal(content, &input); err != nil {
+// Review evaluates every document of a given content using a prepared query and returns the resulting Decision.
+// The file is allowed only if all of its documents are allowed, and every violation records the index of its document,
+// the location of the offending node and the suggestion built from its fix.
+// When reviewed against a base content, the base content is evaluated as well, so the violations are classified as
+// new or unchanged, and the ones no longer found are reported as fixed.
+func (r *reviewer) Review(ctx context.Context, content []byte, opts ...Option) (*Decision, error) {
+	o := new(options)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	documents := []document{removedDocument()}
+	if !o.removed() {
+		parsed, parseErr := parseDocuments(content)
+		if parseErr != nil {
+			return nil, parseErr
+		}
+
+		documents = parsed
+	}
+
+	if !o.diff {
+		return r.evaluate(ctx, content, documents, func(idx int) any {
+			return o.input(&documents[idx], o.extra(nil))
+		})
+	}
+
+	// A base content which cannot be parsed is reviewed as if the file did not exist, so all violations are new.
+	baseDocuments, _ := parseDocuments(o.base)
+	baseDecision, baseErr := r.evaluate(ctx, o.base, baseDocuments, func(idx int) any {
+		return o.input(&baseDocuments[idx], nil)
+	})
+	if baseErr != nil {
+		return nil, fmt.Errorf("failed to review base content: %w", baseErr)
+	}
+
+	// Documents are paired with the base documents by their position among the non-empty documents of the file.
+	decision, err := r.evaluate(ctx, content, documents, func(idx int) any {
+		if idx < len(baseDocuments) {
+			return o.input(&documents[idx], o.extra(baseDocuments[idx].value))
+		}
+
+		return o.input(&documents[idx], o.extra(nil))
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	if input == nil {
-		return nil, errors.New("failed to parse input")
+	classify(decision, baseDecision)
+	return decision, nil
+}
+
+// evaluate evaluates the documents of a content, each of them given the policy input returned for its position.
+func (r *reviewer) evaluate(
+	ctx context.Context,
+	content []byte,
+	documents []document,
+	input func(int) any,
+) (*Decision, error) {
+	decision := &Decision{
+		Allow:      true,
+		Violations: make([]Violation, 0),
+		Documents:  len(documents),
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	for idx := range documents {
+		results, queryErr := r.query.Eval(ctx, rego.EvalInput(input(idx)))
+		if queryErr != nil {
+			return nil, fmt.Errorf("failed to evaluate content: %w", queryErr)
+		}
+
+		docDecision, decodeErr := decodeDecision(results, r.packages)
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		for vIdx := range docDecision.Violations {
+			docDecision.Violations[vIdx].Document = documents[idx].index
+			docDecision.Violations[vIdx].Location = documents[idx].locate(&docDecision.Violations[vIdx])
+			docDecision.Violations[vIdx].Suggestion = documents[idx].suggest(&docDecision.Violations[vIdx], lines)
+		}
+
+		decision.Allow = decision.Allow && docDecision.Allow
+		decision.Violations = append(decision.Violations, docDecision.Violations...)
+	}
+
+	return decision, nil
+}
+
+// evaluateInput evaluates a single input which is not a document of a file, e.g. a changeset, as a single document.
+func (r *reviewer) evaluateInput(ctx context.Context, input map[string]any) (*Decision, error) {
+	results, err := r.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, err
 	}
 
-	results, queryErr := r.query.Eval(ctx, rego.EvalInput(input))
-	if queryErr != nil {
-		return nil, fmt.Errorf("failed to evaluate content: %w", queryErr)
+	decision, decodeErr := decodeDecision(results, r.packages)
+	if decodeErr != nil {
+		return nil, decodeErr
 	}
 
-	resultJSON, _ := json.Marshal(results)
-	return resultJSON, nil
+	decision.Documents = 1
+	return decision, nil
 }
 
 // NewReviewerWithBundle initializes a new Reviewer implementation with a prepared query and returns it.
@@ -45,14 +135,94 @@ func (r *reviewer) Review(ctx context.Context, content []byte) ([]byte, error) {
 // - bundlePath: the path to the OPA bundle to load for evaluation.
 // It returns a Reviewer interface and an error.
 func NewReviewerWithBundle(ctx context.Context, queryStr, bundlePath string) (Reviewer, error) {
+	b, bundleErr := LoadBundle(bundlePath)
+	if bundleErr != nil {
+		return nil, bundleErr
+	}
+
+	return NewReviewer(ctx, queryStr, b)
+}
+
+// LoadBundle loads the OPA bundle from the given path, so it can be shared by multiple Reviewers.
+func LoadBundle(bundlePath string) (*bundle.Bundle, error) {
+	b, err := loader.NewFileLoader().AsBundle(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the opa bundle: %w", err)
+	}
+
+	return b, nil
+}
+
+// NewReviewer initializes a new Reviewer implementation with a query prepared against a loaded bundle.
+// Every package under queryStr which defines deny, warn or info rules is evaluated, if there is none,
+// queryStr itself is evaluated as a single package.
+func NewReviewer(ctx context.Context, queryStr string, b *bundle.Bundle) (Reviewer, error) {
+	r, err := newReviewer(ctx, queryStr, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func newReviewer(ctx context.Context, queryStr string, b *bundle.Bundle) (*reviewer, error) {
+	root, refErr := ast.ParseRef(queryStr)
+	if refErr != nil {
+		return nil, fmt.Errorf("failed to parse the opa policy query: %w", refErr)
+	}
+
+	packages := discoverPackages(b, root)
+	if len(packages) == 0 {
+		packages = []ast.Ref{root}
+	}
+
 	query, err := rego.New(
-		rego.Query(queryStr),
-		rego.LoadBundle(bundlePath),
+		rego.Query(packagesQuery(packages)),
+		rego.ParsedBundle("bundle", b),
 	).PrepareForEval(ctx)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare the opa policy for evaluation: %w", err)
 	}
 
-	return &reviewer{query: query}, nil
+	return &reviewer{query: query, packages: packages}, nil
+}
+
+// discoverPackages returns the packages under root which define rules following the deny, warn and info conventions.
+func discoverPackages(b *bundle.Bundle, root ast.Ref) []ast.Ref {
+	found := make(map[string]ast.Ref)
+	for idx := range b.Modules {
+		module := b.Modules[idx].Parsed
+		if module == nil || !module.Package.Path.HasPrefix(root) {
+			continue
+		}
+
+		for _, rule := range module.Rules {
+			if _, ok := ruleSeverity(rule.Head.Ref()[0].String()); ok {
+				found[module.Package.Path.String()] = module.Package.Path
+				break
+			}
+		}
+	}
+
+	packages := make([]ast.Ref, 0, len(found))
+	for _, pkg := range found {
+		packages = append(packages, pkg)
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Compare(packages[j]) < 0
+	})
+
+	return packages
+}
+
+// packagesQuery builds a query which evaluates to an array of the documents of the given packages.
+func packagesQuery(packages []ast.Ref) string {
+	refs := make([]string, 0, len(packages))
+	for _, pkg := range packages {
+		refs = append(refs, pkg.String())
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(refs, ", "))
 }
@@ -3,6 +3,7 @@ package reviewer
 import (
 	"context"
 	"errors"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,7 +12,7 @@ import (
 func TestReview(t *testing.T) {
 	cases := map[string]struct {
 		input     string
-		expected  string
+		expected  *Decision
 		query     string
 		reviewErr error
 		willPanic bool
@@ -40,8 +41,135 @@ Resources:
           ToPort: 443
           CidrIp: 10.0.0.0/25
 `,
-			query:    "data.reviewer.cfn",
-			expected: `[{"expressions":[{"value":{"allow":false,"violation":["SecurityGroupA"]},"text":"data.reviewer.cfn","location":{"row":1,"col":1}}]}]`, // nolint: lll
+			query: "data.reviewer.cfn",
+			expected: &Decision{
+				Allow: false,
+				Violations: []Violation{
+					{
+						RuleID:     "cfn.security_group.open_ingress",
+						Severity:   SeverityError,
+						Message:    "security group SecurityGroupA allows ingress from 0.0.0.0/0",
+						Package:    "reviewer.cfn",
+						ResourceID: "SecurityGroupA",
+						Path:       "/Resources/SecurityGroupA/Properties/SecurityGroupIngress/0/CidrIp",
+						Location:   Location{StartLine: 12, StartColumn: 11, EndLine: 12, EndColumn: 27},
+					},
+					{
+						RuleID:   "reviewer.cfn.info",
+						Severity: SeverityInfo,
+						Message:  "template has no description",
+						Package:  "reviewer.cfn",
+						Location: Location{StartLine: 2, StartColumn: 1, EndLine: 22, EndColumn: 29},
+					},
+				},
+				Documents: 1,
+			},
+		},
+		"review input with warnings": {
+			input: `
+Description: Security groups
+Resources:
+  SecurityGroupB:
+    Type: AWS::EC2::SecurityGroup
+    Properties:
+      SecurityGroupIngress:
+        - IpProtocol: tcp
+          FromPort: 443
+          ToPort: 443
+          CidrIp: 10.0.0.0/25
+`,
+			query: "data",
+			expected: &Decision{
+				Allow: true,
+				Violations: []Violation{
+					{
+						RuleID:     "cfn.security_group.missing_description",
+						Severity:   SeverityWarning,
+						Message:    "security group SecurityGroupB has no description",
+						Package:    "reviewer.cfn",
+						ResourceID: "SecurityGroupB",
+						Path:       "/Resources/SecurityGroupB/Properties",
+						Location:   Location{StartLine: 6, StartColumn: 5, EndLine: 11, EndColumn: 29},
+					},
+				},
+				Documents: 1,
+			},
+		},
+		"review input without violations": {
+			input: `{"Description": "Empty", "Resources": {}}`,
+			query: "data.reviewer",
+			expected: &Decision{
+				Allow:      true,
+				Violations: []Violation{},
+				Documents:  1,
+			},
+		},
+		"review input with undefined query": {
+			input:     `{}`,
+			query:     "data.reviewer.cfn.undefined",
+			reviewErr: errors.New("policy query returned no result"),
+		},
+		"review input with invalid decision": {
+			input:     `{}`,
+			query:     "data.reviewer.cfn.deny",
+			reviewErr: errors.New("failed to decode decision: json: cannot unmarshal array"),
+		},
+		"review multi-document yaml input": {
+			input: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:1.0.0
+---
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      containers:
+        - name: agent
+          image: agent:latest
+          securityContext:
+            privileged: true
+`,
+			query: "data.reviewer.k8s",
+			expected: &Decision{
+				Allow: false,
+				Violations: []Violation{
+					{
+						RuleID:     "k8s.container.privileged",
+						Severity:   SeverityError,
+						Message:    "container agent of DaemonSet/agent runs as privileged",
+						Package:    "reviewer.k8s",
+						ResourceID: "DaemonSet/agent",
+						Path:       "/spec/template/spec/containers/0/securityContext/privileged",
+						Fix:        privilegedFix(),
+						Document:   2,
+						Location:   Location{StartLine: 25, StartColumn: 13, EndLine: 25, EndColumn: 28},
+						Suggestion: &Suggestion{StartLine: 25, EndLine: 25, Content: "            privileged: false"},
+					},
+					{
+						RuleID:     "k8s.container.latest_tag",
+						Severity:   SeverityWarning,
+						Message:    "container agent of DaemonSet/agent uses the latest image tag",
+						Package:    "reviewer.k8s",
+						ResourceID: "DaemonSet/agent",
+						Path:       "/spec/template/spec/containers/0/image",
+						Document:   2,
+						Location:   Location{StartLine: 23, StartColumn: 11, EndLine: 23, EndColumn: 29},
+					},
+				},
+				Documents: 2,
+			},
 		},
 		"review empty input": {
 			input:     "",
@@ -73,8 +201,13 @@ Resources:
 			}
 
 			result, err := r.Review(context.TODO(), []byte(tc.input))
-			a.Equal(tc.reviewErr, err)
-			a.Equal(tc.expected, string(result))
+			if tc.reviewErr != nil {
+				a.ErrorContains(err, tc.reviewErr.Error())
+				return
+			}
+
+			a.NoError(err)
+			a.Equal(tc.expected, result)
 		})
 	}
 }
@@ -110,3 +243,224 @@ func TestNewReviewerWithBundle(t *testing.T) {
 		})
 	}
 }
+
+func TestNewReviewer(t *testing.T) {
+	a := assert.New(t)
+	b, err := LoadBundle("testdata/bundle.tar.gz")
+	a.NoError(err)
+
+	input := []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:latest
+          securityContext:
+            privileged: true
+`)
+
+	cfnReviewer, cfnErr := NewReviewer(context.TODO(), "data.reviewer.cfn", b)
+	a.NoError(cfnErr)
+
+	k8sReviewer, k8sErr := NewReviewer(context.TODO(), "data.reviewer.k8s", b)
+	a.NoError(k8sErr)
+
+	cfnDecision, cfnReviewErr := cfnReviewer.Review(context.TODO(), input)
+	a.NoError(cfnReviewErr)
+	a.Equal(&Decision{
+		Allow: true,
+		Violations: []Violation{
+			{
+				RuleID:   "reviewer.cfn.info",
+				Severity: SeverityInfo,
+				Message:  "template has no description",
+				Package:  "reviewer.cfn",
+				Location: Location{StartLine: 2, StartColumn: 1, EndLine: 13, EndColumn: 28},
+			},
+		},
+		Documents: 1,
+	}, cfnDecision)
+
+	k8sDecision, k8sReviewErr := k8sReviewer.Review(context.TODO(), input)
+	a.NoError(k8sReviewErr)
+	a.Equal(&Decision{
+		Allow: false,
+		Violations: []Violation{
+			{
+				RuleID:     "k8s.container.privileged",
+				Severity:   SeverityError,
+				Message:    "container app of Deployment/app runs as privileged",
+				Package:    "reviewer.k8s",
+				ResourceID: "Deployment/app",
+				Path:       "/spec/template/spec/containers/0/securityContext/privileged",
+				Fix:        privilegedFix(),
+				Location:   Location{StartLine: 13, StartColumn: 13, EndLine: 13, EndColumn: 28},
+				Suggestion: &Suggestion{StartLine: 13, EndLine: 13, Content: "            privileged: false"},
+			},
+			{
+				RuleID:     "k8s.container.latest_tag",
+				Severity:   SeverityWarning,
+				Message:    "container app of Deployment/app uses the latest image tag",
+				Package:    "reviewer.k8s",
+				ResourceID: "Deployment/app",
+				Path:       "/spec/template/spec/containers/0/image",
+				Location:   Location{StartLine: 11, StartColumn: 11, EndLine: 11, EndColumn: 27},
+			},
+		},
+		Documents: 1,
+	}, k8sDecision)
+
+	_, queryErr := NewReviewer(context.TODO(), "data.reviewer[", b)
+	a.ErrorContains(queryErr, "failed to parse the opa policy query")
+}
+
+func TestLoadBundle(t *testing.T) {
+	a := assert.New(t)
+	_, err := LoadBundle("invalid_bundle_path")
+	a.ErrorContains(err, "failed to load the opa bundle")
+}
+
+func TestReview_CloudFormationTemplate(t *testing.T) {
+	a := assert.New(t)
+	content, readErr := os.ReadFile("testdata/template.yaml")
+	a.NoError(readErr)
+
+	r, err := NewReviewerWithBundle(context.TODO(), "data.reviewer.cfn", "testdata/bundle.tar.gz")
+	a.NoError(err)
+
+	decision, reviewErr := r.Review(context.TODO(), content)
+	a.NoError(reviewErr)
+	a.Equal(&Decision{
+		Allow: false,
+		Violations: []Violation{
+			{
+				RuleID:     "cfn.security_group.open_ingress",
+				Severity:   SeverityError,
+				Message:    "security group SecurityGroup allows ingress from 0.0.0.0/0",
+				Package:    "reviewer.cfn",
+				ResourceID: "SecurityGroup",
+				Path:       "/Resources/SecurityGroup/Properties/SecurityGroupIngress/1/CidrIp",
+				Location:   Location{StartLine: 35, StartColumn: 11, EndLine: 35, EndColumn: 27},
+			},
+		},
+		Documents: 1,
+	}, decision)
+}
+
+func TestReview_RemovedFile(t *testing.T) {
+	a := assert.New(t)
+	r, err := NewReviewerWithBundle(context.TODO(), "data.reviewer.cfn", "testdata/bundle.tar.gz")
+	a.NoError(err)
+
+	decision, reviewErr := r.Review(
+		context.TODO(),
+		nil,
+		WithChange(Change{Path: "stack/app.yaml", Status: ChangeStatusRemoved, Deletions: 10}),
+	)
+	a.NoError(reviewErr)
+	a.Equal(&Decision{Allow: true, Violations: []Violation{}, Documents: 1}, decision)
+
+	_, parseErr := r.Review(context.TODO(), nil, WithChange(Change{Path: "stack/app.yaml", Status: "added"}))
+	a.ErrorContains(parseErr, "failed to parse input")
+}
+
+func TestReview_Base(t *testing.T) {
+	a := assert.New(t)
+	r, err := NewReviewerWithBundle(context.TODO(), "data.reviewer.k8s", "testdata/bundle.tar.gz")
+	a.NoError(err)
+
+	base := []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:latest
+          securityContext:
+            privileged: true
+`)
+
+	head := []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:latest
+`)
+
+	decision, reviewErr := r.Review(context.TODO(), head, WithBase(base))
+	a.NoError(reviewErr)
+	a.Equal(&Decision{
+		Allow: true,
+		Violations: []Violation{
+			{
+				RuleID:     "k8s.container.latest_tag",
+				Severity:   SeverityWarning,
+				Message:    "container app of Deployment/app uses the latest image tag",
+				Package:    "reviewer.k8s",
+				ResourceID: "Deployment/app",
+				Path:       "/spec/template/spec/containers/0/image",
+				Location:   Location{StartLine: 11, StartColumn: 11, EndLine: 11, EndColumn: 27},
+				Change:     ViolationUnchanged,
+			},
+		},
+		Fixed: []Violation{
+			{
+				RuleID:     "k8s.container.privileged",
+				Severity:   SeverityError,
+				Message:    "container app of Deployment/app runs as privileged",
+				Package:    "reviewer.k8s",
+				ResourceID: "Deployment/app",
+				Path:       "/spec/template/spec/containers/0/securityContext/privileged",
+				Fix:        privilegedFix(),
+				Location:   Location{StartLine: 13, StartColumn: 13, EndLine: 13, EndColumn: 28},
+				Change:     ViolationFixed,
+			},
+		},
+		Documents: 1,
+	}, decision)
+
+	added, addedErr := r.Review(context.TODO(), base, WithBase(nil))
+	a.NoError(addedErr)
+	a.Equal([]ViolationChange{ViolationNew, ViolationNew}, []ViolationChange{
+		added.Violations[0].Change,
+		added.Violations[1].Change,
+	})
+	a.Empty(added.Fixed)
+}
+
+func TestOptions_Extra(t *testing.T) {
+	a := assert.New(t)
+	o := new(options)
+	for _, opt := range []Option{WithChange(Change{Path: "app.yaml", Status: "modified"}), WithBase([]byte("{}"))} {
+		opt(o)
+	}
+
+	a.Equal(map[string]any{
+		"change": map[string]any{"path": "app.yaml", "status": "modified", "additions": 0, "deletions": 0},
+		"base":   map[string]any{"kind": "Secret"},
+	}, o.extra(map[string]any{"kind": "Secret"}))
+	a.Equal(map[string]any{}, new(options).extra(nil))
+}
+
+func privilegedFix() *Fix {
+	return &Fix{Patch: []PatchOperation{{
+		Op:    "replace",
+		Path:  "/spec/template/spec/containers/0/securityContext/privileged",
+		Value: false,
+	}}}
+}
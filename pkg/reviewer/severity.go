@@ -0,0 +1,52 @@
+package reviewer
+
+import (
+	"strings"
+)
+
+// Severity indicates how serious a violation is. Only error level violations block a file.
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// ruleSeverities maps the conftest style rule names to the severity of the violations they produce.
+var ruleSeverities = map[string]Severity{
+	"deny": SeverityError,
+	"warn": SeverityWarning,
+	"info": SeverityInfo,
+}
+
+// Rank returns the ordering of the severity, unknown severities are ranked lowest.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityError:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// Max returns the higher of the two severities.
+func (s Severity) Max(other Severity) Severity {
+	if other.Rank() > s.Rank() {
+		return other
+	}
+
+	return s
+}
+
+// ruleSeverity returns the severity of a rule named after the deny, warn and info conventions,
+// either as the exact name (e.g. deny) or as a prefix followed by an underscore (e.g. deny_open_ingress).
+func ruleSeverity(name string) (Severity, bool) {
+	prefix, _, _ := strings.Cut(name, "_")
+	severity, ok := ruleSeverities[prefix]
+	return severity, ok
+}
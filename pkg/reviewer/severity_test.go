@@ -0,0 +1,72 @@
+package reviewer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeverity_Max(t *testing.T) {
+	cases := map[string]struct {
+		severity Severity
+		other    Severity
+		expected Severity
+	}{
+		"error is higher than warning": {
+			severity: SeverityWarning,
+			other:    SeverityError,
+			expected: SeverityError,
+		},
+		"warning is higher than info": {
+			severity: SeverityWarning,
+			other:    SeverityInfo,
+			expected: SeverityWarning,
+		},
+		"info is higher than empty severity": {
+			other:    SeverityInfo,
+			expected: SeverityInfo,
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.severity.Max(tc.other))
+		})
+	}
+}
+
+func TestRuleSeverity(t *testing.T) {
+	cases := map[string]struct {
+		rule     string
+		expected Severity
+		ok       bool
+	}{
+		"deny rule": {
+			rule:     "deny",
+			expected: SeverityError,
+			ok:       true,
+		},
+		"prefixed warn rule": {
+			rule:     "warn_missing_description",
+			expected: SeverityWarning,
+			ok:       true,
+		},
+		"info rule": {
+			rule:     "info",
+			expected: SeverityInfo,
+			ok:       true,
+		},
+		"unknown rule": {
+			rule: "information",
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			severity, ok := ruleSeverity(tc.rule)
+			a.Equal(tc.expected, severity)
+			a.Equal(tc.ok, ok)
+		})
+	}
+}
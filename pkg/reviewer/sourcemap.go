@@ -0,0 +1,236 @@
+package reviewer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Location is the position of a node in the reviewed file. Lines and columns start from 1, the end column is the
+// column of the last character and is 0 when unknown (e.g. block scalars).
+type Location struct {
+	StartLine   int `json:"start_line"`
+	StartColumn int `json:"start_column"`
+	EndLine     int `json:"end_line"`
+	EndColumn   int `json:"end_column"`
+}
+
+// Path is the JSON pointer of the offending node of a violation, e.g. /Resources/SG/Properties/SecurityGroupIngress/0.
+// Policies may return the path as a JSON pointer, a dotted path (e.g. Resources.SG.Properties.SecurityGroupIngress[0])
+// or an array of keys and indexes (e.g. ["Resources", "SG", "Properties", "SecurityGroupIngress", 0]).
+type Path string
+
+var pathIndexPattern = regexp.MustCompile(`\[(\d+)]`)
+
+// UnmarshalJSON converts the path returned by a policy into a JSON pointer.
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case nil:
+		*p = ""
+	case string:
+		*p = parsePath(v)
+	case []any:
+		tokens := make([]string, 0, len(v))
+		for _, token := range v {
+			switch t := token.(type) {
+			case string:
+				tokens = append(tokens, t)
+			case float64:
+				tokens = append(tokens, strconv.FormatFloat(t, 'f', -1, 64))
+			default:
+				return fmt.Errorf("path must only contain keys and indexes, got %T", token)
+			}
+		}
+
+		*p = Path(pointer(tokens...))
+	default:
+		return fmt.Errorf("path must be a string or an array, got %T", value)
+	}
+
+	return nil
+}
+
+// parsePath converts a JSON pointer or a dotted path into a JSON pointer.
+func parsePath(path string) Path {
+	if path == "" || strings.HasPrefix(path, "/") {
+		return Path(path)
+	}
+
+	tokens := make([]string, 0)
+	for _, segment := range strings.Split(path, ".") {
+		key := pathIndexPattern.ReplaceAllString(segment, "")
+		if key != "" {
+			tokens = append(tokens, key)
+		}
+
+		for _, index := range pathIndexPattern.FindAllStringSubmatch(segment, -1) {
+			tokens = append(tokens, index[1])
+		}
+	}
+
+	return Path(pointer(tokens...))
+}
+
+// pointer builds a JSON pointer from the given reference tokens.
+func pointer(tokens ...string) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		sb.WriteString("/")
+		sb.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+
+	return sb.String()
+}
+
+// sourceMap maps the JSON pointer of every node in a document to its location in the file.
+type sourceMap map[string]sourceEntry
+
+// sourceEntry is the location of a node, which starts at the key of a mapping entry, along with the node itself.
+type sourceEntry struct {
+	location Location
+	node     *yaml.Node
+}
+
+// newSourceMap builds the source map of a document from its root node and the lines of the file.
+func newSourceMap(root *yaml.Node, lines []string) sourceMap {
+	sm := make(sourceMap)
+	sm.add(root, lines, "", root.Line, root.Column)
+	return sm
+}
+
+// add records the location of a node, which starts at the given line and column (the key of a mapping entry),
+// and of all of its descendants. Nodes tagged with CloudFormation short-form tags are recorded under their
+// long-form paths as well.
+func (sm sourceMap) add(node *yaml.Node, lines []string, path string, line, column int) {
+	endLine, endColumn := nodeEnd(node, lines)
+	entry := sourceEntry{
+		location: Location{StartLine: line, StartColumn: column, EndLine: endLine, EndColumn: endColumn},
+		node:     node,
+	}
+	sm[path] = entry
+
+	if key, ok := cfnKey(node.Tag); ok {
+		path += pointer(key)
+		sm[path] = entry
+
+		if node.Tag == getAttTag && node.Kind == yaml.ScalarNode {
+			sm[path+pointer("0")] = entry
+			sm[path+pointer("1")] = entry
+		}
+	}
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for idx, item := range node.Content {
+			sm.add(item, lines, path+pointer(strconv.Itoa(idx)), item.Line, item.Column)
+		}
+	case yaml.MappingNode:
+		for idx := 0; idx+1 < len(node.Content); idx += 2 {
+			key, item := node.Content[idx], node.Content[idx+1]
+			if key.ShortTag() == mergeTag {
+				continue
+			}
+
+			sm.add(item, lines, path+pointer(key.Value), key.Line, key.Column)
+		}
+	}
+}
+
+// locate returns the location of the given path, or of its closest recorded ancestor.
+func (sm sourceMap) locate(path string) (Location, bool) {
+	for {
+		if entry, ok := sm[path]; ok {
+			return entry.location, true
+		}
+
+		idx := strings.LastIndex(path, "/")
+		if idx < 0 {
+			return Location{}, false
+		}
+
+		path = path[:idx]
+	}
+}
+
+// nodeEnd returns the line and column of the last character of a node. The width of a quoted scalar is read from the
+// lines of the file, as its value is unescaped, and its end column is unknown when it spans several lines.
+func nodeEnd(node *yaml.Node, lines []string) (line, column int) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode, yaml.MappingNode:
+		if len(node.Content) > 0 {
+			return nodeEnd(node.Content[len(node.Content)-1], lines)
+		}
+
+		return node.Line, node.Column + 1
+	case yaml.AliasNode:
+		return node.Line, node.Column + len(node.Value)
+	default:
+		values := strings.Split(strings.TrimSuffix(node.Value, "\n"), "\n")
+		if node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+			// The content of a block scalar starts on the line after its indicator.
+			return node.Line + len(values), 0
+		}
+
+		if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0 {
+			if end := quotedEnd(lines, node); end > 0 {
+				return node.Line, end
+			}
+
+			return node.Line + len(values) - 1, 0
+		}
+
+		if len(values) > 1 {
+			return node.Line + len(values) - 1, 0
+		}
+
+		width := utf8.RuneCountInString(node.Value)
+		if node.Style&yaml.TaggedStyle != 0 {
+			width += utf8.RuneCountInString(node.Tag) + 1
+		}
+
+		return node.Line, node.Column + max(width, 1) - 1
+	}
+}
+
+// quotedEnd returns the column of the closing quote of a quoted scalar, skipping the escaped characters of double
+// quoted scalars and the doubled quotes of single quoted ones, or 0 if it is not closed on the line of the node.
+func quotedEnd(lines []string, node *yaml.Node) int {
+	if node.Line < 1 || node.Line > len(lines) {
+		return 0
+	}
+
+	quote := '\''
+	if node.Style&yaml.DoubleQuotedStyle != 0 {
+		quote = '"'
+	}
+
+	line := []rune(strings.TrimSuffix(lines[node.Line-1], "\r"))
+	start := node.Column - 1
+	for start >= 0 && start < len(line) && line[start] != quote {
+		start++
+	}
+
+	for idx := start + 1; idx < len(line); idx++ {
+		switch {
+		case quote == '"' && line[idx] == '\\':
+			idx++
+		case line[idx] != quote:
+		case quote == '\'' && idx+1 < len(line) && line[idx+1] == quote:
+			idx++
+		default:
+			return idx + 1
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,129 @@
+package reviewer
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath_UnmarshalJSON(t *testing.T) {
+	cases := map[string]struct {
+		path     string
+		expected Path
+		errMsg   *string
+	}{
+		"dotted path": {
+			path:     `"Resources.SG.Properties.SecurityGroupIngress[0].CidrIp"`,
+			expected: "/Resources/SG/Properties/SecurityGroupIngress/0/CidrIp",
+		},
+		"dotted path with nested indexes": {
+			path:     `"matrix[1][2]"`,
+			expected: "/matrix/1/2",
+		},
+		"json pointer": {
+			path:     `"/spec/containers/0/image"`,
+			expected: "/spec/containers/0/image",
+		},
+		"array of keys and indexes": {
+			path:     `["metadata", "annotations", "example.com/owner", 0]`,
+			expected: "/metadata/annotations/example.com~1owner/0",
+		},
+		"null path": {
+			path:     `null`,
+			expected: "",
+		},
+		"array with invalid element should return error": {
+			path:   `["spec", true]`,
+			errMsg: strPtr("path must only contain keys and indexes, got bool"),
+		},
+		"invalid path should return error": {
+			path:   `{}`,
+			errMsg: strPtr("path must be a string or an array, got map[string]interface {}"),
+		},
+	}
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			a := assert.New(t)
+			var path Path
+			err := json.Unmarshal([]byte(tc.path), &path)
+
+			if tc.errMsg != nil {
+				a.ErrorContains(err, *tc.errMsg)
+				return
+			}
+
+			a.NoError(err)
+			a.Equal(tc.expected, path)
+		})
+	}
+}
+
+func TestDocument_Locate(t *testing.T) {
+	content := `Resources:
+  Function:
+    Type: AWS::Lambda::Function
+    Properties:
+      Role: !GetAtt Role.Arn
+      Code:
+        ZipFile: |
+          exports.handler = async () => {};
+          module.exports = {};
+      Tags: []
+      Description: "function"
+      Handler: "index\thandler"
+      Runtime: 'node''s é'
+`
+
+	cases := map[string]struct {
+		violation Violation
+		expected  Location
+	}{
+		"locate path": {
+			violation: Violation{Path: "/Resources/Function/Properties/Description"},
+			expected:  Location{StartLine: 11, StartColumn: 7, EndLine: 11, EndColumn: 29},
+		},
+		"locate long-form path of short-form tag": {
+			violation: Violation{Path: "/Resources/Function/Properties/Role/Fn::GetAtt/1"},
+			expected:  Location{StartLine: 5, StartColumn: 7, EndLine: 5, EndColumn: 28},
+		},
+		"locate escaped double quoted scalar": {
+			violation: Violation{Path: "/Resources/Function/Properties/Handler"},
+			expected:  Location{StartLine: 12, StartColumn: 7, EndLine: 12, EndColumn: 31},
+		},
+		"locate single quoted scalar with doubled quote and multi-byte character": {
+			violation: Violation{Path: "/Resources/Function/Properties/Runtime"},
+			expected:  Location{StartLine: 13, StartColumn: 7, EndLine: 13, EndColumn: 26},
+		},
+		"locate block scalar": {
+			violation: Violation{Path: "/Resources/Function/Properties/Code"},
+			expected:  Location{StartLine: 6, StartColumn: 7, EndLine: 9, EndColumn: 0},
+		},
+		"locate empty flow sequence": {
+			violation: Violation{Path: "/Resources/Function/Properties/Tags"},
+			expected:  Location{StartLine: 10, StartColumn: 7, EndLine: 10, EndColumn: 14},
+		},
+		"locate closest ancestor of missing path": {
+			violation: Violation{Path: "/Resources/Function/Properties/Timeout"},
+			expected:  Location{StartLine: 4, StartColumn: 5, EndLine: 13, EndColumn: 26},
+		},
+		"locate cloudformation resource": {
+			violation: Violation{ResourceID: "Function"},
+			expected:  Location{StartLine: 2, StartColumn: 3, EndLine: 13, EndColumn: 26},
+		},
+		"locate document": {
+			violation: Violation{ResourceID: "Unknown"},
+			expected:  Location{StartLine: 1, StartColumn: 1, EndLine: 13, EndColumn: 26},
+		},
+	}
+
+	documents, err := parseDocuments([]byte(content))
+	assert.NoError(t, err)
+
+	for n, tc := range cases {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, tc.expected, documents[0].locate(&tc.violation))
+		})
+	}
+}
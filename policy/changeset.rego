@@ -0,0 +1,29 @@
+package reviewer.changeset
+
+import rego.v1
+
+prod_changes := [file.path | some file in input.files; startswith(file.path, "stack/prod/")]
+
+stacks := {file.path | some file in input.files; file.type == "cloudformation"}
+
+changelog_changed if {
+    some file in input.files
+    file.path == "CHANGELOG.md"
+}
+
+deny contains violation if {
+    count(prod_changes) > 0
+    not changelog_changed
+    violation := {
+        "rule": "changeset.changelog",
+        "msg": sprintf("changes to %s require a CHANGELOG.md entry", [concat(", ", prod_changes)]),
+    }
+}
+
+deny contains violation if {
+    count(stacks) > 1
+    violation := {
+        "rule": "changeset.single_stack",
+        "msg": sprintf("only one stack may be changed at a time, found %s", [concat(", ", sort(stacks))]),
+    }
+}
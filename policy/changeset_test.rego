@@ -0,0 +1,27 @@
+package reviewer.changeset_test
+
+import data.reviewer.changeset.deny
+
+test_deny_when_prod_stack_changes_without_changelog {
+    deny == {{
+        "rule": "changeset.changelog",
+        "msg": "changes to stack/prod/app.yaml require a CHANGELOG.md entry",
+    }} with input as {"files": [{"path": "stack/prod/app.yaml", "type": "cloudformation"}]}
+}
+
+test_not_deny_when_prod_stack_changes_with_changelog {
+    count(deny) == 0 with input as {"files": [
+        {"path": "stack/prod/app.yaml", "type": "cloudformation"},
+        {"path": "CHANGELOG.md", "type": ""},
+    ]}
+}
+
+test_deny_when_multiple_stacks_change {
+    deny == {{
+        "rule": "changeset.single_stack",
+        "msg": "only one stack may be changed at a time, found stack/app.yaml, stack/db.yaml",
+    }} with input as {"files": [
+        {"path": "stack/db.yaml", "type": "cloudformation"},
+        {"path": "stack/app.yaml", "type": "cloudformation"},
+    ]}
+}
@@ -0,0 +1,37 @@
+package reviewer.k8s
+
+import rego.v1
+
+workloads := {"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job"}
+
+pod_spec_path := ["spec"] if input.kind == "Pod"
+
+pod_spec_path := ["spec", "template", "spec"] if input.kind in workloads
+
+pod_spec := object.get(input, pod_spec_path, {})
+
+resource := sprintf("%s/%s", [input.kind, input.metadata.name])
+
+deny contains violation if {
+    some idx, container in pod_spec.containers
+    container.securityContext.privileged == true
+    path := array.concat(pod_spec_path, ["containers", idx, "securityContext", "privileged"])
+    violation := {
+        "rule": "k8s.container.privileged",
+        "msg": sprintf("container %s of %s runs as privileged", [container.name, resource]),
+        "resource": resource,
+        "path": path,
+        "fix": {"patch": [{"op": "replace", "path": path, "value": false}]},
+    }
+}
+
+warn contains violation if {
+    some idx, container in pod_spec.containers
+    endswith(container.image, ":latest")
+    violation := {
+        "rule": "k8s.container.latest_tag",
+        "msg": sprintf("container %s of %s uses the latest image tag", [container.name, resource]),
+        "resource": resource,
+        "path": array.concat(pod_spec_path, ["containers", idx, "image"]),
+    }
+}
@@ -0,0 +1,50 @@
+package reviewer.k8s_test
+
+import data.reviewer.k8s.deny
+import data.reviewer.k8s.warn
+
+mock_input(kind, container) = {
+  "apiVersion": "apps/v1",
+  "kind": kind,
+  "metadata": {
+    "name": "app"
+  },
+  "spec": {
+    "template": {
+      "spec": {
+        "containers": [container]
+      }
+    }
+  }
+}
+
+test_deny_when_container_is_privileged {
+    deny == {{
+        "rule": "k8s.container.privileged",
+        "msg": "container app of Deployment/app runs as privileged",
+        "resource": "Deployment/app",
+        "path": ["spec", "template", "spec", "containers", 0, "securityContext", "privileged"],
+        "fix": {"patch": [{
+            "op": "replace",
+            "path": ["spec", "template", "spec", "containers", 0, "securityContext", "privileged"],
+            "value": false,
+        }]},
+    }} with input as mock_input("Deployment", {"name": "app", "image": "app:1.0.0", "securityContext": {"privileged": true}})
+}
+
+test_not_deny_when_container_is_not_privileged {
+    count(deny) == 0 with input as mock_input("Deployment", {"name": "app", "image": "app:1.0.0"})
+}
+
+test_warn_when_container_uses_latest_tag {
+    warn == {{
+        "rule": "k8s.container.latest_tag",
+        "msg": "container app of StatefulSet/app uses the latest image tag",
+        "resource": "StatefulSet/app",
+        "path": ["spec", "template", "spec", "containers", 0, "image"],
+    }} with input as mock_input("StatefulSet", {"name": "app", "image": "app:latest"})
+}
+
+test_not_warn_for_unknown_kind {
+    count(warn) == 0 with input as mock_input("Service", {"name": "app", "image": "app:latest"})
+}
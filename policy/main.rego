@@ -2,14 +2,33 @@ package reviewer.cfn
 
 import rego.v1
 
-default allow := false
-
-allow if {
-    count(violation) == 0
+deny contains violation if {
+    some id, idx
+    input.Resources[id].Type == "AWS::EC2::SecurityGroup"
+    input.Resources[id].Properties.SecurityGroupIngress[idx].CidrIp == "0.0.0.0/0"
+    violation := {
+        "rule": "cfn.security_group.open_ingress",
+        "msg": sprintf("security group %s allows ingress from 0.0.0.0/0", [id]),
+        "resource": id,
+        "path": ["Resources", id, "Properties", "SecurityGroupIngress", idx, "CidrIp"],
+    }
 }
 
-violation contains id if {
+warn contains violation if {
     some id
     input.Resources[id].Type == "AWS::EC2::SecurityGroup"
-    input.Resources[id].Properties.SecurityGroupIngress[_].CidrIp == "0.0.0.0/0"
+    not input.Resources[id].Properties.GroupDescription
+    violation := {
+        "rule": "cfn.security_group.missing_description",
+        "msg": sprintf("security group %s has no description", [id]),
+        "resource": id,
+        "path": ["Resources", id, "Properties"],
+    }
+}
+
+removed if input.change.status == "removed"
+
+info contains "template has no description" if {
+    not removed
+    not input.Description
 }
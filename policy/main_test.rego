@@ -1,12 +1,16 @@
 package reviewer.cfn_test
 
-import data.reviewer.cfn.allow
+import data.reviewer.cfn.deny
+import data.reviewer.cfn.info
+import data.reviewer.cfn.warn
 
 mock_input(cidr) = {
+  "Description": "Security group",
   "Resources": {
     "SecurityGroup": {
       "Type": "AWS::EC2::SecurityGroup",
       "Properties": {
+        "GroupDescription": "Allow HTTP",
         "VpcId": {
           "Ref": "VPC"
         },
@@ -23,10 +27,32 @@ mock_input(cidr) = {
   }
 }
 
-test_not_allow_when_ingress_cidrip_is_anywhere {
-    not allow with input as mock_input("0.0.0.0/0")
+test_deny_when_ingress_cidrip_is_anywhere {
+    deny == {{
+        "rule": "cfn.security_group.open_ingress",
+        "msg": "security group SecurityGroup allows ingress from 0.0.0.0/0",
+        "resource": "SecurityGroup",
+        "path": ["Resources", "SecurityGroup", "Properties", "SecurityGroupIngress", 0, "CidrIp"],
+    }} with input as mock_input("0.0.0.0/0")
 }
 
-test_allow_when_ingress_cidrip_is_not_anywhere {
-    allow with input as mock_input("10.0.0.0/25")
+test_not_deny_when_ingress_cidrip_is_not_anywhere {
+    count(deny) == 0 with input as mock_input("10.0.0.0/25")
+}
+
+test_warn_when_security_group_has_no_description {
+    warn == {{
+        "rule": "cfn.security_group.missing_description",
+        "msg": "security group SecurityGroup has no description",
+        "resource": "SecurityGroup",
+        "path": ["Resources", "SecurityGroup", "Properties"],
+    }} with input as json.remove(mock_input("10.0.0.0/25"), ["Resources/SecurityGroup/Properties/GroupDescription"])
+}
+
+test_info_when_template_has_no_description {
+    info == {"template has no description"} with input as json.remove(mock_input("10.0.0.0/25"), ["Description"])
+}
+
+test_not_info_when_template_is_removed {
+    count(info) == 0 with input as {"change": {"path": "stack/app.yaml", "status": "removed"}}
 }
@@ -0,0 +1,29 @@
+package reviewer.prod
+
+import rego.v1
+
+stateful_types := {"AWS::DynamoDB::Table", "AWS::RDS::DBInstance", "AWS::S3::Bucket"}
+
+production if startswith(input.file.path, "stack/prod/")
+
+deny contains violation if {
+    production
+    input.base_ref != "main"
+    violation := {
+        "rule": "prod.base_ref",
+        "msg": sprintf("production stack %s must be merged into main, found %s", [input.file.path, input.base_ref]),
+    }
+}
+
+deny contains violation if {
+    production
+    some id
+    input.document.Resources[id].Type in stateful_types
+    object.get(input.document.Resources[id], "DeletionPolicy", "Delete") != "Retain"
+    violation := {
+        "rule": "prod.deletion_policy",
+        "msg": sprintf("production resource %s must retain its data", [id]),
+        "resource": id,
+        "path": ["Resources", id],
+    }
+}
@@ -0,0 +1,43 @@
+package reviewer.prod_test
+
+import data.reviewer.prod.deny
+
+mock_input(path, base_ref, policy) = {
+  "document": {
+    "Resources": {
+      "Table": {
+        "Type": "AWS::DynamoDB::Table",
+        "DeletionPolicy": policy
+      }
+    }
+  },
+  "file": {
+    "path": path,
+    "type": "cloudformation"
+  },
+  "base_ref": base_ref
+}
+
+test_not_deny_when_production_table_is_retained {
+    count(deny) == 0 with input as mock_input("stack/prod/app.yaml", "main", "Retain")
+}
+
+test_deny_when_production_table_is_not_retained {
+    deny == {{
+        "rule": "prod.deletion_policy",
+        "msg": "production resource Table must retain its data",
+        "resource": "Table",
+        "path": ["Resources", "Table"],
+    }} with input as mock_input("stack/prod/app.yaml", "main", "Delete")
+}
+
+test_deny_when_production_stack_is_not_merged_into_main {
+    deny == {{
+        "rule": "prod.base_ref",
+        "msg": "production stack stack/prod/app.yaml must be merged into main, found develop",
+    }} with input as mock_input("stack/prod/app.yaml", "develop", "Retain")
+}
+
+test_not_deny_when_stack_is_not_production {
+    count(deny) == 0 with input as mock_input("stack/dev/app.yaml", "develop", "Delete")
+}
@@ -0,0 +1,32 @@
+package reviewer.pull_request
+
+import rego.v1
+
+title_pattern := `^(build|chore|ci|docs|feat|fix|perf|refactor|revert|style|test)(\([\w./-]+\))?!?: .+`
+
+deny contains violation if {
+    input.pull_request
+    not regex.match(title_pattern, input.pull_request.title)
+    violation := {
+        "rule": "pull_request.title",
+        "msg": sprintf("title %q does not follow the conventional commits format", [input.pull_request.title]),
+    }
+}
+
+deny contains violation if {
+    count(input.pull_request.linked_issues) == 0
+    not "no-issue" in input.pull_request.labels
+    violation := {
+        "rule": "pull_request.linked_issue",
+        "msg": "pull request must link an issue, or be labeled no-issue",
+    }
+}
+
+warn contains violation if {
+    some message in input.pull_request.commit_messages
+    regex.match(`^(fixup|squash)! `, message)
+    violation := {
+        "rule": "pull_request.fixup_commit",
+        "msg": sprintf("commit %q should be squashed before merging", [message]),
+    }
+}
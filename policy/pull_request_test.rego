@@ -0,0 +1,42 @@
+package reviewer.pull_request_test
+
+import data.reviewer.pull_request.deny
+import data.reviewer.pull_request.warn
+
+mock_input(title, labels, issues, messages) = {
+  "pull_request": {
+    "title": title,
+    "labels": labels,
+    "linked_issues": issues,
+    "commit_messages": messages
+  }
+}
+
+test_not_deny_when_pull_request_is_valid {
+    count(deny) == 0 with input as mock_input("feat(stack): add queue", [], [2], ["feat: add queue"])
+}
+
+test_deny_when_title_is_not_conventional {
+    deny == {{
+        "rule": "pull_request.title",
+        "msg": "title \"Add queue\" does not follow the conventional commits format",
+    }} with input as mock_input("Add queue", [], [2], [])
+}
+
+test_deny_when_no_issue_is_linked {
+    deny == {{
+        "rule": "pull_request.linked_issue",
+        "msg": "pull request must link an issue, or be labeled no-issue",
+    }} with input as mock_input("fix: typo", [], [], [])
+}
+
+test_not_deny_when_labeled_no_issue {
+    count(deny) == 0 with input as mock_input("fix: typo", ["no-issue"], [], [])
+}
+
+test_warn_when_fixup_commit_is_found {
+    warn == {{
+        "rule": "pull_request.fixup_commit",
+        "msg": "commit \"fixup! fix: typo\" should be squashed before merging",
+    }} with input as mock_input("fix: typo", [], [2], ["fix: typo", "fixup! fix: typo"])
+}